@@ -2,9 +2,8 @@ package zli
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -12,40 +11,161 @@ import (
 var (
 	progname = ""
 	version  = "dev"
+	commit   = ""
+	date     = ""
 )
 
 // GetVersion gets this program's version.
 func GetVersion() (tag string, commit string, date time.Time) {
 	b, ok := debug.ReadBuildInfo()
-	if !ok {
+	if !ok && !hasBuildMeta() {
 		return version, "failed reading detailed build info", time.Time{}
 	}
+	v := readVersionInfo(b)
+	return version, v.Commit, v.Date
+}
 
-	var vcs string
-	for _, s := range b.Settings {
-		switch s.Key {
-		case "vcs.revision":
-			commit = s.Value
-		case "vcs.time":
-			date, _ = time.Parse(time.RFC3339, s.Value)
-		case "vcs":
-			vcs = s.Value
+func hasBuildMeta() bool { return commit != "" || date != "" }
+
+// Version gets this program's version as a string, in the same format as
+// PrintVersion() uses:
+//
+//	v1.2.3 336b4c73 2024-06-07
+//
+// The tag is omitted if zgo.at/zli.version isn't set, and "(modified)" is
+// appended if there were uncommitted changes.
+func Version() string {
+	info, _ := debug.ReadBuildInfo()
+	return versionString(info)
+}
+
+// VersionInfo is the parsed version of this program.
+type VersionInfo struct {
+	Tag      string    // Tag as set with zgo.at/zli.version; "" if not set.
+	Major    int       // Major version from the tag (0 if not set).
+	Minor    int       // Minor version from the tag (0 if not set).
+	Patch    int       // Patch version from the tag (0 if not set).
+	Commit   string    // VCS revision; shortened to 8 characters for git.
+	Date     time.Time // VCS commit time.
+	Modified bool      // Built with uncommitted changes.
+}
+
+// GetVersionInfo gets this program's version.
+func GetVersionInfo() VersionInfo {
+	info, _ := debug.ReadBuildInfo()
+	return readVersionInfo(info)
+}
+
+// VersionAtLeast reports if this program's version tag is equal to or higher
+// than min (e.g. "v2.1.0").
+//
+// This always reports true if the version tag isn't set, as that's assumed to
+// be a development version.
+func VersionAtLeast(min string) bool {
+	if version == "" || version == "dev" {
+		return true
+	}
+	return compareVersion(version, min) >= 0
+}
+
+func readVersionInfo(info *debug.BuildInfo) VersionInfo {
+	var (
+		v   VersionInfo
+		vcs string
+	)
+	if version != "" && version != "dev" {
+		v.Tag = version
+		n := strings.SplitN(strings.TrimPrefix(trimPrerelease(version), "v"), ".", 3)
+		for i, p := range []*int{&v.Major, &v.Minor, &v.Patch} {
+			if i < len(n) {
+				*p, _ = strconv.Atoi(n[i])
+			}
+		}
+	}
+	if info != nil {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				v.Commit = s.Value
+			case "vcs.time":
+				v.Date, _ = time.Parse(time.RFC3339, s.Value)
+			case "vcs.modified":
+				v.Modified = s.Value == "true"
+			case "vcs":
+				vcs = s.Value
+			}
 		}
 	}
-	if vcs == "git" && len(commit) > 8 {
-		commit = commit[:8]
+	if vcs == "git" && len(v.Commit) > 8 {
+		v.Commit = v.Commit[:8]
+	}
+	if commit != "" {
+		v.Commit = commit
+	}
+	if date != "" {
+		v.Date = parseBuildDate(date)
+	}
+	return v
+}
+
+// parseBuildDate parses the date set with zgo.at/zli.date; this can be in
+// RFC3339 format, as a date ("2006-01-02"), or as a Unix timestamp (e.g. from
+// SOURCE_DATE_EPOCH).
+func parseBuildDate(d string) time.Time {
+	if n, err := strconv.ParseInt(d, 10, 64); err == nil {
+		return time.Unix(n, 0).UTC()
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, d); err == nil {
+			return t
+		}
 	}
-	return version, commit, date
+	return time.Time{}
 }
 
+func versionString(info *debug.BuildInfo) string {
+	vi := readVersionInfo(info)
+	v := make([]string, 0, 4)
+	if vi.Tag != "" {
+		v = append(v, vi.Tag)
+	}
+	if vi.Commit != "" {
+		v = append(v, vi.Commit)
+	}
+	if !vi.Date.IsZero() {
+		v = append(v, vi.Date.Format("2006-01-02"))
+	}
+	if vi.Modified {
+		v = append(v, "(modified)")
+	}
+	return strings.Join(v, " ")
+}
+
+// VersionFormat is the format PrintVersion uses. The following placeholders
+// are replaced:
+//
+//	%(prog)      Program()
+//	%(version)   Version()
+//	%(go)        Go version used to build the program.
+//	%(os)        GOOS
+//	%(arch)      GOARCH
+//	%(race)      true or false, depending on if built with -race.
+//	%(cgo)       true or false, depending on if CGO_ENABLED was set.
+//
+// For example to print just the program name and version:
+//
+//	zli.VersionFormat = "%(prog) %(version)"
+var VersionFormat = "%(prog) %(version); %(go) %(os)/%(arch); race=%(race); cgo=%(cgo)"
+
 // PrintVersion prints this program's version.
 //
 // The format is:
 //
 //	prog 336b4c73 2024-06-07; go1.22.4 linux/amd64; race=false; cgo=false
 //
-// Where prog is os.Args[0], followed by the commit and date of the commit. You
-// can print a tagged version by setting zgo.at/zli.version at build time:
+// Where prog is Program(), followed by the commit and date of the commit; the
+// format can be changed with VersionFormat. You can print a tagged version by
+// setting zgo.at/zli.version at build time:
 //
 //	go build -ldflags "-X zgo.at/zli.version=v1.2.3"
 //
@@ -53,26 +173,37 @@ func GetVersion() (tag string, commit string, date time.Time) {
 //
 //	elles v1.2.3 336b4c73 2024-06-07; go1.22.4 linux/amd64; race=false; cgo=true
 //
-// In addition, zgo.at/zli.progname can be set to override os.Args[0]:
+// The commit and date are read from the VCS information that "go build" adds.
+// This isn't available when building from a source tarball, in which case
+// zgo.at/zli.commit and zgo.at/zli.date can be set; the date can be in RFC3339
+// format, as "2006-01-02", or a Unix timestamp:
+//
+//	go build -ldflags '-X "zgo.at/zli.commit=336b4c73" -X "zgo.at/zli.date=$SOURCE_DATE_EPOCH"'
+//
+// These override the VCS information if set.
+//
+// In addition, zgo.at/zli.progname can be set to override os.Args[0] (this is
+// the same as using SetProgram()):
 //
 //	go build -ldflags '-X "zgo.at/zli.version=VERSION" -X "zgo.at/zli.progname=PROG"'
 //
 // If verbose is true it also prints detailed build information (similar to "go
-// version -m bin")
+// version -m bin"): the module, dependencies (see VersionDeps), and build
+// settings.
 func PrintVersion(verbose bool) {
-	if progname == "" && len(os.Args) > 0 {
-		progname = filepath.Base(os.Args[0])
-	}
-
 	info, ok := debug.ReadBuildInfo()
 	if !ok {
+		if hasBuildMeta() {
+			fmt.Fprintln(Stdout, Program(), versionString(nil))
+			return
+		}
 		fmt.Fprintln(Stdout, "failed reading detailed build info")
+		return
 	}
 
 	var (
-		race, cgo, mod            bool
-		goos, goarch, commit, vcs string
-		date                      time.Time
+		race, cgo    bool
+		goos, goarch string
 	)
 	for _, s := range info.Settings {
 		switch s.Key {
@@ -84,38 +215,68 @@ func PrintVersion(verbose bool) {
 			goarch = s.Value
 		case "GOOS":
 			goos = s.Value
-		case "vcs.revision":
-			commit = s.Value
-		case "vcs.time":
-			date, _ = time.Parse(time.RFC3339, s.Value)
-		case "vcs.modified":
-			mod = s.Value == "true"
-		case "vcs":
-			vcs = s.Value
 		}
 	}
-	if vcs == "git" && len(commit) > 8 {
-		commit = commit[:8]
-	}
 
-	v := make([]string, 0, 4)
-	if version != "" && version != "dev" {
-		v = append(v, version)
-	}
-	if commit != "" {
-		v = append(v, commit)
+	fmt.Fprintln(Stdout, strings.NewReplacer(
+		"%(prog)", Program(),
+		"%(version)", versionString(info),
+		"%(go)", info.GoVersion,
+		"%(os)", goos,
+		"%(arch)", goarch,
+		"%(race)", strconv.FormatBool(race),
+		"%(cgo)", strconv.FormatBool(cgo),
+	).Replace(VersionFormat))
+
+	if verbose {
+		printBuildInfo(info)
 	}
-	if !date.IsZero() {
-		v = append(v, date.Format("2006-01-02"))
+}
+
+// VersionDeps limits the dependencies shown by PrintVersion(true) to modules
+// starting with one of these prefixes; all dependencies are shown if it's
+// empty.
+var VersionDeps []string
+
+func printBuildInfo(info *debug.BuildInfo) {
+	fmt.Fprintf(Stdout, "\npath    %s\nmodule  %s %s\n", info.Path, info.Main.Path, info.Main.Version)
+
+	deps := make([]*debug.Module, 0, len(info.Deps))
+	for _, d := range info.Deps {
+		if len(VersionDeps) == 0 {
+			deps = append(deps, d)
+			continue
+		}
+		for _, p := range VersionDeps {
+			if strings.HasPrefix(d.Path, p) {
+				deps = append(deps, d)
+				break
+			}
+		}
 	}
-	if mod {
-		v = append(v, "(modified)")
+	if len(deps) > 0 {
+		var wPath, wVersion int
+		for _, d := range deps {
+			wPath, wVersion = max(wPath, len(d.Path)), max(wVersion, len(d.Version))
+		}
+		fmt.Fprintln(Stdout, "\ndependencies:")
+		for _, d := range deps {
+			l := fmt.Sprintf("    %-*s  %-*s  %s", wPath, d.Path, wVersion, d.Version, d.Sum)
+			if d.Replace != nil {
+				l += fmt.Sprintf("  => %s %s %s", d.Replace.Path, d.Replace.Version, d.Replace.Sum)
+			}
+			fmt.Fprintln(Stdout, strings.TrimRight(l, " "))
+		}
 	}
 
-	fmt.Fprintf(Stdout, "%s %s; %s %s/%s; race=%t; cgo=%t\n",
-		progname, strings.Join(v, " "), info.GoVersion, goos, goarch, race, cgo)
-
-	if verbose && info != nil {
-		fmt.Fprint(Stdout, "\n", info)
+	if len(info.Settings) > 0 {
+		fmt.Fprintln(Stdout, "\nbuild settings:")
+		w := 0
+		for _, s := range info.Settings {
+			w = max(w, len(s.Key))
+		}
+		for _, s := range info.Settings {
+			fmt.Fprintf(Stdout, "    %-*s  %s\n", w, s.Key, s.Value)
+		}
 	}
 }
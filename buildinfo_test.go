@@ -0,0 +1,153 @@
+package zli
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1", "1", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2", "v1.2.0", 0},
+		{"v1.10", "v1.9", 1},
+		{"v1.9", "v1.10", -1},
+		{"v2", "v1.99.99", 1},
+		{"v1.2.3-rc1", "v1.2.3", -1},
+		{"v1.2.3", "v1.2.3-rc1", 1},
+		{"v1.2.3-rc2", "v1.2.3-rc1", 1},
+		{"v1.2.3+build", "v1.2.3", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			have := compareVersion(tt.a, tt.b)
+			if have != tt.want {
+				t.Errorf("have: %d; want: %d", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionInfo(t *testing.T) {
+	defer func(v string) { version = v }(version)
+
+	version = "dev"
+	if !VersionAtLeast("v99") {
+		t.Error("dev not newer")
+	}
+	if v := GetVersionInfo(); v.Tag != "" || v.Major != 0 {
+		t.Errorf("%#v", v)
+	}
+
+	version = "v2.1.3-rc1"
+	v := GetVersionInfo()
+	if v.Tag != "v2.1.3-rc1" || v.Major != 2 || v.Minor != 1 || v.Patch != 3 {
+		t.Errorf("%#v", v)
+	}
+	if !VersionAtLeast("v2.1.0") || !VersionAtLeast("v2") || VersionAtLeast("v2.1.3") || VersionAtLeast("v2.2") {
+		t.Error("wrong VersionAtLeast")
+	}
+}
+
+func TestPrintBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		Path: "zgo.at/prog",
+		Main: debug.Module{Path: "zgo.at/prog", Version: "v1.0.0"},
+		Deps: []*debug.Module{
+			{Path: "zgo.at/zli", Version: "v1.2.3", Sum: "h1:aaa="},
+			{Path: "golang.org/x/sys", Version: "v0.1.0", Sum: "h1:bbb=",
+				Replace: &debug.Module{Path: "../sys", Version: "(devel)"}},
+		},
+		Settings: []debug.BuildSetting{{Key: "-compiler", Value: "gc"}, {Key: "GOOS", Value: "linux"}},
+	}
+
+	tests := []struct {
+		filter []string
+		want   string
+	}{
+		{nil, `
+			path    zgo.at/prog
+			module  zgo.at/prog v1.0.0
+
+			dependencies:
+			    zgo.at/zli        v1.2.3  h1:aaa=
+			    golang.org/x/sys  v0.1.0  h1:bbb=  => ../sys (devel)
+
+			build settings:
+			    -compiler  gc
+			    GOOS       linux
+		`},
+		{[]string{"zgo.at/"}, `
+			path    zgo.at/prog
+			module  zgo.at/prog v1.0.0
+
+			dependencies:
+			    zgo.at/zli  v1.2.3  h1:aaa=
+
+			build settings:
+			    -compiler  gc
+			    GOOS       linux
+		`},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			_, _, out := Test(t)
+			VersionDeps = tt.filter
+			defer func() { VersionDeps = nil }()
+
+			printBuildInfo(info)
+			want := "\n" + strings.ReplaceAll(strings.TrimSpace(tt.want), "\n\t\t\t", "\n") + "\n"
+			if have := out.String(); have != want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, want)
+			}
+		})
+	}
+}
+
+func TestBuildMeta(t *testing.T) {
+	defer func() { commit, date = "", "" }()
+
+	tests := []struct {
+		commit, date string
+		want         string
+	}{
+		{"abcdef12", "2024-06-07", "abcdef12 2024-06-07"},
+		{"abcdef12", "2024-06-07T12:00:00Z", "abcdef12 2024-06-07"},
+		{"", "1717718400", "2024-06-07"},
+		{"abcdef12", "", "abcdef12"},
+		{"abcdef12", "not a date", "abcdef12"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			commit, date = tt.commit, tt.date
+			have := versionString(&debug.BuildInfo{Settings: []debug.BuildSetting{
+				{Key: "vcs", Value: "git"},
+				{Key: "vcs.revision", Value: "1111111111111111"},
+			}})
+			if tt.commit == "" {
+				tt.want = "11111111 " + tt.want
+			}
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionFormat(t *testing.T) {
+	_, _, out := Test(t)
+	defer func(f string) { VersionFormat = f }(VersionFormat)
+
+	VersionFormat = "[%(prog)] %(os)"
+	PrintVersion(false)
+	if have, want := out.String(), "[zli.test] "+runtime.GOOS+"\n"; have != want {
+		t.Errorf("\nhave: %q\nwant: %q", have, want)
+	}
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -29,12 +30,16 @@ func ranges(n ...int) []uint8 {
 }
 
 func main() {
+	want := zli.WantColor
 	zli.WantColor = true
 	bg := false
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "bg":
 			bg = true
+		case "report":
+			report(want)
+			return
 		case "brighten":
 			if len(os.Args) != 3 {
 				zli.Fatalf("specify a color:\n  colortest brighten 26\n  colortest brighten #123123")
@@ -48,7 +53,7 @@ func main() {
 				fmt.Printf("Background: %sXXX%s\n", c.Bg(), zli.Reset)
 				return
 			}
-			zli.Fatalf("unknown command; supported commands: 'bg', 'brighten'")
+			zli.Fatalf("unknown command; supported commands: 'bg', 'brighten', 'report'")
 		}
 	}
 	toBg := func(c zli.Color) zli.Color {
@@ -109,6 +114,58 @@ func main() {
 	fmt.Printf("\nRun '%s bg' to set background instead of foreground.\n", zli.Program())
 	fmt.Printf("Run '%s brighten [color]' to test the Brighten() method.\n", zli.Program())
 	fmt.Printf("Run '%s #color' to test true colour.\n", zli.Program())
+	fmt.Printf("Run '%s report' to show the detected color support.\n", zli.Program())
+}
+
+// report prints what zli detected about the terminal, and a true color gradient
+// to verify it works.
+func report(want bool) {
+	env := func(k string) string {
+		v, ok := os.LookupEnv(k)
+		if !ok {
+			return "(not set)"
+		}
+		return fmt.Sprintf("%q", v)
+	}
+	support := "16 colors"
+	switch ct := os.Getenv("COLORTERM"); {
+	case ct == "truecolor" || ct == "24bit":
+		support = "true color (from COLORTERM)"
+	case strings.Contains(os.Getenv("TERM"), "256color"):
+		support = "256 colors (from TERM)"
+	}
+
+	fmt.Printf("zli.WantColor:    %t\n", want)
+	fmt.Printf("Stdout terminal:  %t\n", zli.IsTerminal(os.Stdout.Fd()))
+	fmt.Printf("TERM:             %s\n", env("TERM"))
+	fmt.Printf("COLORTERM:        %s\n", env("COLORTERM"))
+	fmt.Printf("NO_COLOR:         %s\n", env("NO_COLOR"))
+	fmt.Printf("Probably support: %s\n", support)
+
+	w, _, _ := zli.TerminalSize(os.Stdout.Fd())
+	if w <= 0 {
+		w = 80
+	}
+	fmt.Print("\n256 colors: ")
+	for i := 0; i < w-12; i++ {
+		fmt.Print(zli.Color256(uint8(232+i*24/(w-12))).Bg(), " ")
+	}
+	fmt.Print(zli.Reset, "\nTrue color: ")
+	for i := 0; i < w-12; i++ {
+		r, g, b := hue(float64(i) / float64(w-12))
+		fmt.Print(zli.ColorHex(fmt.Sprintf("#%02x%02x%02x", r, g, b)).Bg(), " ")
+	}
+	fmt.Println(zli.Reset)
+	fmt.Println("\nThe true color bar should be a smooth gradient without visible bands.")
+}
+
+// hue gets the RGB values for the hue h, from 0 to 1.
+func hue(h float64) (uint8, uint8, uint8) {
+	c := func(n float64) uint8 {
+		k := math.Mod(n+h*6, 6)
+		return uint8(255 * (1 - math.Max(0, math.Min(1, math.Min(k, 4-k)))))
+	}
+	return c(5), c(3), c(1)
 }
 
 func brightTest(name string) {
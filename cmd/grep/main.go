@@ -3,40 +3,40 @@ package main
 
 import (
 	"bufio"
-	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 
 	"zgo.at/zli"
 )
 
-var usage = zli.Usage(zli.UsageTrim|zli.UsageHeaders|zli.UsageFlags, `
+var usage = zli.Usage(zli.UsageTrim|zli.UsageHeaders|zli.UsageFlags|zli.UsageAlign, `
 Usage: grep [options..] pattern [file..]
 
 grep searches for a pattern in each file.
 
 Options:
-    pattern
-        A regular expression.
+    pattern              A regular expression.
+    file [file..]        Zero or more files; if none are given read from
+                         stdin, or the current directory with -r.
 
-    file [file..]
-        Zero or more files; if none are given read from stdin.
-
-    -h, -help
-        Show this help.
-
-    -o, -only-matching
-        Print only the matching part, instead the entire line.
-
-    -q, -quiet, -silent
-        Don't show any output, exit with 0 on the first match found.
-
-    -color=when, -colour=when
-        When to display colors: auto (default), never, or always.
-
-    -p, -pager
-        Pipe the output to $PAGER.
+    -h, -help              Show this help.
+    -o, -only-matching     Print only the matching part, instead the entire
+                           line.
+    -q, -quiet, -silent    Don't show any output, exit with 0 on the first
+                           match found.
+    -c, -count             Print only the number of matching lines for every
+                           file.
+    -r, -recursive         Search all files in directories.
+    -A n, -after=n         Print n lines of context after every match.
+    -B n, -before=n        Print n lines of context before every match.
+    -C n, -context=n       Print n lines of context before and after every
+                           match.
+    -color=when            When to display colors: auto (default), never, or
+                           always. -colour is accepted as an alias.
+    -p, -pager             Pipe the output to $PAGER.
 
 Exit code:
     0 if a pattern is found, 1 if nothing is found, 2 if there was an error.
@@ -47,8 +47,14 @@ const (
 	colorMatch  = zli.Red
 	colorLineNr = zli.Magenta
 	colorPath   = zli.Bold | zli.Underline
+	colorSep    = zli.Cyan
 )
 
+type options struct {
+	only, silent, count, pager bool
+	before, after              int
+}
+
 func main() {
 	// Set the exit code for zli.F() and zli.Fatalf().
 	zli.ExitCode = 2
@@ -56,16 +62,21 @@ func main() {
 	// Parse the flags.
 	f := zli.NewFlags(os.Args)
 	var (
-		help   = f.Bool(false, "h", "help")
-		only   = f.Bool(false, "o", "only-matching")
-		silent = f.Bool(false, "q", "quiet", "silent")
-		pager  = f.Bool(false, "p", "pager")
-		color  = f.String("auto", "color", "colour")
+		help      = f.Bool(false, "h", "help")
+		only      = f.Bool(false, "o", "only-matching")
+		silent    = f.Bool(false, "q", "quiet", "silent")
+		count     = f.Bool(false, "c", "count")
+		recursive = f.Bool(false, "r", "recursive")
+		after     = f.Int(0, "A", "after")
+		before    = f.Int(0, "B", "before")
+		context   = f.Int(0, "C", "context")
+		pager     = f.Bool(false, "p", "pager")
+		color     = f.Enum("auto", []string{"auto", "always", "never"}, "color", "colour")
 	)
-	err := f.Parse()
-	if err != nil {
-		zli.Fatalf(err)
-	}
+	// Positional() makes Parse() return an error if there isn't at least one
+	// positional argument (the pattern). The flags are still set if there's an
+	// error, so we can check -help first.
+	err := f.Parse(zli.Positional(1, 0))
 
 	// The flag value needs to be retrieved through a Bool() (or String(),
 	// Int(), etc.); this avoids having to deal with pointers.
@@ -76,34 +87,50 @@ func main() {
 	// at all; this can be useful to disambiguate between zero values such as an
 	// empty string or 0, and the flag not being present on the commandline.
 	if help.Bool() {
-		fmt.Print(usage)
+		zli.Print(usage)
 		return
 	}
+	zli.F(err)
 
+	// Enum() flags are validated by Parse(), so there's no need to check for
+	// invalid values here.
 	switch color.String() {
-	case "auto": // Do nothing.
 	case "always":
-		zli.WantColor = true
+		zli.WantColor, zli.WantColorStderr = true, true
 	case "never":
-		zli.WantColor = false
-	default:
-		zli.Fatalf("invalid value for -color: %q", color.String())
+		zli.WantColor, zli.WantColorStderr = false, false
+	}
+
+	opt := options{
+		only:   only.Bool(),
+		silent: silent.Bool(),
+		count:  count.Bool(),
+		pager:  pager.Bool(),
+		before: before.Int(),
+		after:  after.Int(),
+	}
+	if context.Set() {
+		if !before.Set() {
+			opt.before = context.Int()
+		}
+		if !after.Set() {
+			opt.after = context.Int()
+		}
 	}
 
 	// Shift() removes and returns the first positional argument, or returns an
 	// empty string if there aren't any positional arguments left. In this case,
 	// the first positional argument is the regexp we want to match with.
-	patt := f.Shift()
-	if patt == "" {
-		zli.Fatalf("need a pattern")
-	}
-	re, err := regexp.Compile(patt)
+	re, err := regexp.Compile(f.Shift())
 	zli.F(err)
 
 	// Read from stdin if there are no files given. InputOrFile() will take care
 	// of this.
 	if len(f.Args) == 0 {
 		f.Args = []string{"-"}
+		if recursive.Bool() {
+			f.Args = []string{"."}
+		}
 	}
 
 	// Collect output in a memory buffer so we can send it to the pager.
@@ -114,67 +141,135 @@ func main() {
 
 	exit := 1 // Nothing selected is exit 1
 	for _, path := range f.Args {
-		// Read either the file or stdin (if "" or "-").
-		fp, err := zli.InputOrFile(path, false)
-		zli.F(err)
-		defer fp.Close()
-
-		var (
-			shownPath = false
-			scan      = bufio.NewScanner(fp)
-			lineNr    = int64(0)
-		)
-		for scan.Scan() {
-			l := scan.Text()
-			lineNr++
-
-			match := re.FindAllStringIndex(l, -1)
-			if len(match) == 0 {
-				continue
-			}
+		paths := []string{path}
+		if recursive.Bool() && path != "-" {
+			paths = nil
+			err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.Type().IsRegular() {
+					paths = append(paths, p)
+				}
+				return nil
+			})
+			zli.F(err)
+		}
 
-			// We can exit in -quiet mode on the first match.
-			//
-			// Can also use Bool(), but it doesn't really matter, and Set()
-			// reads a bit nicer IMHO :-)
-			if silent.Set() {
-				zli.Exit(0)
+		for _, p := range paths {
+			if grep(re, p, opt) {
+				exit = 0
 			}
-			exit = 0
-
-			// Apply the color highlighting for the matches, loop over the
-			// matches in reverse order so the inserted color codes for the
-			// first match won't affect the string indexing for the second
-			// match.
-			for i := len(match) - 1; i >= 0; i-- {
-				m := match[i]
-				if only.Set() {
-					l = zli.Colorize(l[m[0]:m[1]], colorMatch)
-				} else {
-					l = l[:m[0]] + zli.Colorize(l[m[0]:m[1]], colorMatch) + l[m[1]:]
-				}
+		}
+	}
+
+	runPager()
+	zli.Exit(exit)
+}
+
+// grep searches for re in path, and reports if there was at least one match.
+func grep(re *regexp.Regexp, path string, opt options) bool {
+	// Read either the file or stdin (if "" or "-").
+	fp, err := zli.InputOrFile(path, false)
+	zli.F(err)
+	defer fp.Close()
+
+	var (
+		isFile    = path != "" && path != "-"
+		shownPath = false
+		scan      = bufio.NewScanner(fp)
+		lineNr    = 0
+		matches   = 0
+		printed   = 0      // Last printed line number.
+		afterLeft = 0      // Context lines after a match left to print.
+		before    []string // Context lines before a match.
+	)
+
+	// print a line; sep is ":" for matching lines and "-" for context lines,
+	// as in GNU grep.
+	print := func(nr int, l, sep string) {
+		if printed > 0 && nr > printed+1 && (opt.before > 0 || opt.after > 0) {
+			zli.Colorln("--", colorSep)
+		}
+		printed = nr
+
+		if isFile {
+			if opt.pager || !zli.IsTerminal(os.Stdout.Fd()) {
+				// Not a terminal: print file path for every line.
+				zli.Print(path, sep)
+			} else if !shownPath {
+				// Print file path as a header once on interactive terminals.
+				zli.Colorln(path, colorPath)
+				shownPath = true
 			}
+		}
+
+		// We print to zli.Stdout (with zli.Println) instead of using
+		// os.Stdout as this can be swapped out in tests (see zli.Test()).
+		// This is also how zli.PagerStdout() works: everything is written
+		// to a buffer and displayed when we're done.
+		zli.Println(zli.Colorize(strconv.Itoa(nr), colorLineNr) + sep + l)
+	}
+
+	for scan.Scan() {
+		l := scan.Text()
+		lineNr++
 
-			if path != "" && path != "-" {
-				if pager.Set() || !zli.IsTerminal(os.Stdout.Fd()) {
-					// Not a terminal: print file path for every line.
-					fmt.Fprint(zli.Stdout, path, ":")
-				} else if !shownPath {
-					// Print file path as a header once on interactive terminals.
-					zli.Colorln(path, colorPath)
-					shownPath = true
+		match := re.FindAllStringIndex(l, -1)
+		if len(match) == 0 {
+			if opt.count {
+				continue
+			}
+			if afterLeft > 0 {
+				print(lineNr, l, "-")
+				afterLeft--
+			} else if opt.before > 0 {
+				before = append(before, l)
+				if len(before) > opt.before {
+					before = before[1:]
 				}
 			}
+			continue
+		}
 
-			// We print to zli.Stdout instead of using os.Stdout as this can be
-			// swapped out in tests (see zli.Test()). This is also how
-			// zli.PagerStdout() works: everything is written to a buffer and
-			// displayed when we're done.
-			fmt.Fprintln(zli.Stdout,
-				zli.Colorize(strconv.FormatInt(lineNr, 10), colorLineNr)+":"+l)
+		// We can exit in -quiet mode on the first match.
+		//
+		// Can also use Bool(), but it doesn't really matter, and Set()
+		// reads a bit nicer IMHO :-)
+		if opt.silent {
+			zli.Exit(0)
+		}
+		matches++
+		if opt.count {
+			continue
 		}
+
+		for i, b := range before {
+			print(lineNr-len(before)+i, b, "-")
+		}
+		before, afterLeft = before[:0], opt.after
+
+		// Apply the color highlighting for the matches, loop over the
+		// matches in reverse order so the inserted color codes for the
+		// first match won't affect the string indexing for the second
+		// match.
+		for i := len(match) - 1; i >= 0; i-- {
+			m := match[i]
+			if opt.only {
+				l = zli.Colorize(l[m[0]:m[1]], colorMatch)
+			} else {
+				l = l[:m[0]] + zli.Colorize(l[m[0]:m[1]], colorMatch) + l[m[1]:]
+			}
+		}
+		print(lineNr, l, ":")
 	}
+	zli.F(scan.Err())
 
-	runPager()
-	zli.Exit(exit)
+	if opt.count {
+		if isFile {
+			zli.Print(zli.Colorize(path, colorPath), ":")
+		}
+		zli.Println(matches)
+	}
+	return matches > 0
 }
@@ -63,6 +63,52 @@ func TestGrep(t *testing.T) {
 			[]string{"grep", "-q", "^package", "main.go"},
 			"", "", "", 0,
 		},
+		// -c flag
+		{
+			[]string{"grep", "-c", "^package", "main.go"},
+			"",
+			"\x1b[1;4mmain.go\x1b[0m:1\n",
+			"main.go:1\n",
+			0,
+		},
+		{
+			[]string{"grep", "-c", "x"},
+			"x\ny\nx\n",
+			"grep: reading from stdin...\r2\n",
+			"2\n",
+			0,
+		},
+		// Context
+		{
+			[]string{"grep", "-C1", "x"},
+			"1\n2\nx\n4\n5\n6\nx\n8\n",
+			"grep: reading from stdin...\r\x1b[35m2\x1b[0m-2\n\x1b[35m3\x1b[0m:\x1b[31mx\x1b[0m\n\x1b[35m4\x1b[0m-4\n\x1b[36m--\x1b[0m\n\x1b[35m6\x1b[0m-6\n\x1b[35m7\x1b[0m:\x1b[31mx\x1b[0m\n\x1b[35m8\x1b[0m-8\n",
+			"2-2\n3:x\n4-4\n--\n6-6\n7:x\n8-8\n",
+			0,
+		},
+		{
+			[]string{"grep", "-B", "2", "-A=1", "x"},
+			"1\n2\nx\nx\n5\n6\n",
+			"grep: reading from stdin...\r\x1b[35m1\x1b[0m-1\n\x1b[35m2\x1b[0m-2\n\x1b[35m3\x1b[0m:\x1b[31mx\x1b[0m\n\x1b[35m4\x1b[0m:\x1b[31mx\x1b[0m\n\x1b[35m5\x1b[0m-5\n",
+			"1-1\n2-2\n3:x\n4:x\n5-5\n",
+			0,
+		},
+		// -r flag
+		{
+			[]string{"grep", "-r", "-c", "^package", "testdata"},
+			"",
+			"\x1b[1;4mtestdata/a\x1b[0m:1\n\x1b[1;4mtestdata/dir/b\x1b[0m:0\n",
+			"testdata/a:1\ntestdata/dir/b:0\n",
+			0,
+		},
+		// Need a pattern.
+		{
+			[]string{"grep"},
+			"",
+			"grep: at least 1 positional argument required, but 0 given\n",
+			"grep: at least 1 positional argument required, but 0 given\n",
+			2,
+		},
 	}
 
 	for i, tt := range tests {
@@ -214,20 +214,52 @@ func (c Color) String() string {
 	if !WantColor || c&ColorError != 0 {
 		return ""
 	}
+	var buf [64]byte
+	return string(c.appendSeq(buf[:0]))
+}
+
+// Append appends the escape sequence for this color code to dst and returns
+// the extended buffer.
+//
+// Like String(), this doesn't append anything if WantColor is false or if the
+// error flag is set.
+func (c Color) Append(dst []byte) []byte {
+	if !WantColor || c&ColorError != 0 {
+		return dst
+	}
+	return c.appendSeq(dst)
+}
+
+func (c Color) appendSeq(b []byte) []byte {
 	if c == Reset {
-		return "\x1b[0m"
+		return append(b, "\x1b[0m"...)
+	}
+
+	b = append(b, "\x1b["...)
+	start := len(b)
+	sep := func() {
+		if len(b) > start {
+			b = append(b, ';')
+		}
+	}
+	rgb := func(cc Color) {
+		b = strconv.AppendUint(b, uint64(cc%256), 10)
+		b = append(b, ';')
+		b = strconv.AppendUint(b, uint64(cc>>8%256), 10)
+		b = append(b, ';')
+		b = strconv.AppendUint(b, uint64(cc>>16%256), 10)
 	}
 
-	attrs := make([]string, 0, 4)
 	for i := range allAttrs {
 		if c&allAttrs[i] != 0 {
+			sep()
 			switch allAttrs[i] {
 			case Overline:
-				attrs = append(attrs, "53")
+				b = append(b, "53"...)
 			case Undercurl:
-				attrs = append(attrs, "4:3")
+				b = append(b, "4:3"...)
 			default:
-				attrs = append(attrs, strconv.Itoa(i+1))
+				b = strconv.AppendInt(b, int64(i+1), 10)
 			}
 		}
 	}
@@ -238,15 +270,16 @@ func (c Color) String() string {
 		if cc > 37 { // Bright colors
 			cc += 52
 		}
-		attrs = append(attrs, strconv.FormatUint(uint64(cc), 10))
+		sep()
+		b = strconv.AppendUint(b, uint64(cc), 10)
 	case c&ColorMode256Fg != 0:
-		attrs = append(attrs, "38;5;"+strconv.FormatUint(uint64(c&maskFg>>ColorOffsetFg), 10))
+		sep()
+		b = append(b, "38;5;"...)
+		b = strconv.AppendUint(b, uint64(c&maskFg>>ColorOffsetFg), 10)
 	case c&ColorModeTrueFg != 0:
-		cc := c & maskFg >> ColorOffsetFg
-		attrs = append(attrs, "38;2;"+
-			strconv.FormatUint(uint64(cc%256), 10)+";"+
-			strconv.FormatUint(uint64(cc>>8%256), 10)+";"+
-			strconv.FormatUint(uint64(cc>>16%256), 10))
+		sep()
+		b = append(b, "38;2;"...)
+		rgb(c & maskFg >> ColorOffsetFg)
 	}
 
 	switch {
@@ -255,28 +288,19 @@ func (c Color) String() string {
 		if cc > 47 { // Bright colors
 			cc += 52
 		}
-		attrs = append(attrs, strconv.FormatUint(uint64(cc), 10))
+		sep()
+		b = strconv.AppendUint(b, uint64(cc), 10)
 	case c&ColorMode256Bg != 0:
-		attrs = append(attrs, "48;5;"+strconv.FormatUint(uint64(c&maskBg>>ColorOffsetBg), 10))
+		sep()
+		b = append(b, "48;5;"...)
+		b = strconv.AppendUint(b, uint64(c&maskBg>>ColorOffsetBg), 10)
 	case c&ColorModeTrueBg != 0:
-		cc := c & maskBg >> ColorOffsetBg
-		attrs = append(attrs, "48;2;"+
-			strconv.FormatUint(uint64(cc%256), 10)+";"+
-			strconv.FormatUint(uint64(cc>>8%256), 10)+";"+
-			strconv.FormatUint(uint64(cc>>16%256), 10))
+		sep()
+		b = append(b, "48;2;"...)
+		rgb(c & maskBg >> ColorOffsetBg)
 	}
 
-	var b strings.Builder
-	b.Grow(20)             // 1 alloc
-	b.WriteString("\x1b[") // 1 alloc
-	for i, a := range attrs {
-		b.WriteString(a)
-		if len(attrs)-1 != i {
-			b.WriteRune(';')
-		}
-	}
-	b.WriteRune('m')
-	return b.String()
+	return append(b, 'm')
 }
 
 // Color256 creates a new 256-mode color.
@@ -313,19 +337,49 @@ func ColorHex(h string) Color {
 // Colorize the text with a color if WantColor is true.
 //
 // The text will end with the reset code.
-func Colorize(text string, c Color) string {
-	if c == Reset {
+func Colorize(text string, c Color) string { return colorize(WantColor, text, c) }
+
+// colorizeStderr is like Colorize(), but uses WantColorStderr.
+func colorizeStderr(text string, c Color) string { return colorize(WantColorStderr, text, c) }
+
+func colorize(want bool, text string, c Color) string {
+	if c == Reset || !want {
 		return text
 	}
-	if WantColor && c&ColorError != 0 {
+	if c&ColorError != 0 {
 		return "(zli.Color ERROR invalid hex color)" + text
 	}
 
-	attrs := c.String()
-	if attrs == "" {
-		return text
+	var buf [64]byte
+	seq := c.appendSeq(buf[:0])
+
+	var b strings.Builder
+	b.Grow(len(seq) + len(text) + 4) // 1 alloc
+	b.Write(seq)
+	b.WriteString(text)
+	b.WriteString("\x1b[0m")
+	return b.String()
+}
+
+// AppendColorize appends the text colorized with c to dst, and returns the
+// extended buffer.
+//
+// This is the same as Colorize(), but doesn't allocate if dst has enough
+// capacity.
+func AppendColorize(dst []byte, text string, c Color) []byte {
+	if c == Reset {
+		return append(dst, text...)
+	}
+	if WantColor && c&ColorError != 0 {
+		return append(append(dst, "(zli.Color ERROR invalid hex color)"...), text...)
+	}
+
+	n := len(dst)
+	dst = c.Append(dst)
+	if len(dst) == n {
+		return append(dst, text...)
 	}
-	return attrs + text + Reset.String()
+	return append(append(dst, text...), "\x1b[0m"...)
 }
 
 // Colorf prints colorized output if WantColor is true.
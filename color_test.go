@@ -91,6 +91,10 @@ func TestColor(t *testing.T) {
 				if got != "Hello" {
 					t.Errorf("Colorize WantColor not respected? got: %q", got)
 				}
+				got = string(zli.AppendColorize(nil, "Hello", tt.in))
+				if got != "Hello" {
+					t.Errorf("AppendColorize WantColor not respected? got: %q", got)
+				}
 			})
 
 			zli.WantColor = true
@@ -108,6 +112,13 @@ func TestColor(t *testing.T) {
 				}
 			})
 
+			t.Run("AppendColorize", func(t *testing.T) {
+				got := string(zli.AppendColorize([]byte("> "), "Hello", tt.in))
+				if got != "> "+tt.want+"Hello\x1b[0m" {
+					t.Errorf("AppendColorize()\ngot:  %q → %[1]s\nwant: %q → %[2]s", got, tt.want)
+				}
+			})
+
 			t.Run("DeColor", func(t *testing.T) {
 				got := zli.Colorize("Hello", tt.in)
 				de := zli.DeColor(got)
@@ -173,6 +184,9 @@ func TestColor(t *testing.T) {
 }
 
 func BenchmarkColor(b *testing.B) {
+	defer func(c bool) { zli.WantColor = c }(zli.WantColor)
+	zli.WantColor = true
+
 	c := zli.Green | zli.Red.Bg() | zli.Bold | zli.Underline
 	var s string
 
@@ -182,3 +196,34 @@ func BenchmarkColor(b *testing.B) {
 	}
 	_ = s
 }
+
+func BenchmarkAppendColorize(b *testing.B) {
+	defer func(c bool) { zli.WantColor = c }(zli.WantColor)
+	zli.WantColor = true
+
+	c := zli.ColorHex("#678") | zli.Color256(99).Bg() | zli.Bold
+	buf := make([]byte, 0, 64)
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		buf = zli.AppendColorize(buf[:0], "Hello", c)
+	}
+}
+
+func FuzzColorHex(f *testing.F) {
+	f.Add("#678")
+	f.Add("#abcdef")
+	f.Add("abc")
+	f.Add("#12345")
+
+	f.Fuzz(func(t *testing.T, in string) {
+		c := zli.ColorHex(in)
+		if c == zli.ColorError {
+			return
+		}
+		if c&zli.ColorModeTrueFg == 0 {
+			t.Errorf("%q: not a true color: %d", in, c)
+		}
+		_ = c.String()
+	})
+}
@@ -2,101 +2,322 @@ package zli
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
+// Term writes escape sequences to a specific writer, rather than Stdout.
+//
+// This is useful if the output needs to go somewhere else, for example to
+// /dev/tty if stdout is redirected:
+//
+//	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+//	zli.F(err)
+//	t := zli.NewTerm(tty)
+//	t.EraseScreen()
+//	t.To(2, 4, "Hello")
+//
+// The functions such as Erase(), To(), etc. are identical to the Term methods
+// with zli.Stdout.
+type Term struct{ w io.Writer }
+
+// NewTerm creates a new Term which writes to w.
+func NewTerm(w io.Writer) Term { return Term{w: w} }
+
+func stdTerm() Term { return Term{w: Stdout} }
+
+// Write writes p to the writer without modification.
+func (t Term) Write(p []byte) (int, error) { return t.w.Write(p) }
+
 // Erase line from the cursor to the end, leaving the cursor in the current
 // position.
-func Erase() { fmt.Fprint(Stdout, "\x1b[K") }
+func Erase() { stdTerm().Erase() }
+
+// EraseLineStart erases the line from the start to the cursor (inclusive),
+// leaving the cursor in the current position.
+func EraseLineStart() { stdTerm().EraseLineStart() }
+
+// EraseLine erases the entire line, leaving the cursor in the current position.
+func EraseLine() { stdTerm().EraseLine() }
+
+// EraseDown erases the screen from the cursor to the end (the rest of the line
+// and all lines below it), leaving the cursor in the current position.
+func EraseDown() { stdTerm().EraseDown() }
+
+// EraseUp erases the screen from the start to the cursor (all lines above it
+// and the line up to and including the cursor), leaving the cursor in the
+// current position.
+func EraseUp() { stdTerm().EraseUp() }
 
 // Replacef replaces the current line.
-func Replacef(text string, a ...any) {
-	fmt.Fprint(Stdout, "\x1b[K\r")
+func Replacef(text string, a ...any) { stdTerm().Replacef(text, a...) }
+
+// Progress prints progress updates with Replacef(), but writes at most rate
+// updates per second, and skips updates if the text didn't change. This is
+// useful for reporting progress in tight loops:
+//
+//	p := zli.NewProgress(10)
+//	for i, f := range files {
+//	    p.Printf("processing %d/%d", i+1, len(files))
+//	    // ...
+//	}
+//	p.Done()
+//
+// It's safe to use from multiple goroutines.
+type Progress struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+	shown    string
+	pending  *string
+}
+
+// NewProgress creates a new Progress which writes at most rate updates per
+// second; there is no limit if rate is 0 or lower.
+func NewProgress(rate int) *Progress {
+	p := &Progress{}
+	if rate > 0 {
+		p.interval = time.Second / time.Duration(rate)
+	}
+	return p
+}
+
+// Printf replaces the current line with the text, unless the previous update
+// was written too recently; the text is remembered and written by the next
+// Printf() or Done() call.
+func (p *Progress) Printf(text string, a ...any) {
 	if len(a) > 0 {
-		fmt.Fprintf(Stdout, text, a...)
-	} else {
-		fmt.Fprint(Stdout, text)
+		text = fmt.Sprintf(text, a...)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.interval > 0 && time.Since(p.last) < p.interval {
+		p.pending = &text
+		return
+	}
+	p.write(text)
+}
+
+// Done writes the last update if it wasn't written yet.
+func (p *Progress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending != nil {
+		p.write(*p.pending)
 	}
 }
 
+func (p *Progress) write(text string) {
+	p.pending = nil
+	if text == p.shown && !p.last.IsZero() {
+		return
+	}
+	Replacef("%s", text)
+	p.shown, p.last = text, time.Now()
+}
+
 // EraseScreen erases the entire screen and puts the cursor at position 1, 1.
-func EraseScreen() { fmt.Fprint(Stdout, "\x1b[0;0H\x1b[J") }
+func EraseScreen() { stdTerm().EraseScreen() }
+
+var (
+	hideMu     sync.Mutex
+	hideCount  int
+	hideAtExit bool
+	hideSig    chan os.Signal
+)
 
 // HideCursor hides the cursor, returning a function to display it again.
+//
+// Calls can be nested: the cursor is only displayed again once the functions
+// for all HideCursor() calls have been called. Calling the returned function
+// more than once does nothing.
+//
+// The cursor is always displayed again if the program exits with Exit(),
+// Fatalf(), etc. (see AtExit()), or if the program is interrupted with a signal
+// such as SIGINT or SIGTERM while the cursor is hidden. Signals are left alone
+// while a SignalContext() is active, so the program can still shut down
+// gracefully; the cursor is displayed again if it then exits with Exit().
 func HideCursor() func() {
-	fmt.Fprint(Stdout, "\x1b[?25l")
-	return func() { fmt.Fprint(Stdout, "\x1b[?25h") }
-}
+	hideMu.Lock()
+	defer hideMu.Unlock()
 
-func max(x int, y ...int) int {
-	m := x
-	for _, yy := range y {
-		if yy > m {
-			m = yy
+	hideCount++
+	if hideCount == 1 {
+		fmt.Fprint(Stdout, "\x1b[?25l")
+		if !hideAtExit {
+			hideAtExit = true
+			AtExit(showCursorAtExit)
 		}
+
+		hideSig = make(chan os.Signal, 1)
+		signal.Notify(hideSig, exitSignals...)
+		go func(ch chan os.Signal) {
+			for sig := range ch {
+				// Leave it to SignalContext() to shut down gracefully; the
+				// cursor is displayed again from AtExit().
+				if atomic.LoadInt32(&signalContexts) > 0 {
+					continue
+				}
+				code := 1
+				if n, ok := sig.(syscall.Signal); ok {
+					code = 128 + int(n)
+				}
+				defaultExit(code)
+				return
+			}
+		}(hideSig)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			hideMu.Lock()
+			defer hideMu.Unlock()
+			hideCount--
+			if hideCount == 0 {
+				showCursor()
+			}
+		})
+	}
+}
+
+func showCursorAtExit() {
+	hideMu.Lock()
+	defer hideMu.Unlock()
+	hideAtExit = false
+	if hideCount > 0 {
+		hideCount = 0
+		showCursor()
 	}
-	return m
+}
+
+func showCursor() {
+	fmt.Fprint(Stdout, "\x1b[?25h")
+	signal.Stop(hideSig)
+	close(hideSig)
 }
 
 // To sets the cursor at the given position and prints the text.
 //
 // The top-left corner is 1, 1.
-func To(row, col int, text string, a ...any) {
-	fmt.Fprintf(Stdout, "\x1b[%d;%dH", max(row, 1), max(col, 1))
-	if text != "" {
-		if len(a) > 0 {
-			fmt.Fprintf(Stdout, text, a...)
-		} else {
-			fmt.Fprint(Stdout, text)
-		}
-	}
-}
+func To(row, col int, text string, a ...any) { stdTerm().To(row, col, text, a...) }
 
 // Move the cursor relative to current position and print the text.
 //
 // Positive values move down or right, negative values move up or left, and 0
 // doesn't move anything.
-func Move(row, col int, text string, a ...any) {
+func Move(row, col int, text string, a ...any) { stdTerm().Move(row, col, text, a...) }
+
+// Modify text, inserting or deleting lines, and print the text.
+//
+// On positive values it will insert text, moving existing text below (for
+// lines) or to the right (for characters). On negative values it will delete
+// text, moving existing text upwards (for lines) or to the left (for
+// characters). On 0 nothing is modified.
+func Modify(line, char int, text string, a ...any) { stdTerm().Modify(line, char, text, a...) }
+
+// Erase is like Erase(), but writes to t.
+func (t Term) Erase() { fmt.Fprint(t.w, "\x1b[K") }
+
+// EraseLineStart is like EraseLineStart(), but writes to t.
+func (t Term) EraseLineStart() { fmt.Fprint(t.w, "\x1b[1K") }
+
+// EraseLine is like EraseLine(), but writes to t.
+func (t Term) EraseLine() { fmt.Fprint(t.w, "\x1b[2K") }
+
+// EraseDown is like EraseDown(), but writes to t.
+func (t Term) EraseDown() { fmt.Fprint(t.w, "\x1b[J") }
+
+// EraseUp is like EraseUp(), but writes to t.
+func (t Term) EraseUp() { fmt.Fprint(t.w, "\x1b[1J") }
+
+// Replacef is like Replacef(), but writes to t.
+func (t Term) Replacef(text string, a ...any) {
+	fmt.Fprint(t.w, "\x1b[K\r")
+	t.print(text, a...)
+}
+
+// EraseScreen is like EraseScreen(), but writes to t.
+func (t Term) EraseScreen() { fmt.Fprint(t.w, "\x1b[0;0H\x1b[J") }
+
+// HideCursor is like HideCursor(), but writes to t.
+//
+// Unlike HideCursor() calls can't be nested, and the cursor isn't displayed
+// again on exit.
+func (t Term) HideCursor() func() {
+	fmt.Fprint(t.w, "\x1b[?25l")
+	return func() { fmt.Fprint(t.w, "\x1b[?25h") }
+}
+
+// To is like To(), but writes to t.
+func (t Term) To(row, col int, text string, a ...any) {
+	fmt.Fprintf(t.w, "\x1b[%d;%dH", max(row, 1), max(col, 1))
+	t.print(text, a...)
+}
+
+// Move is like Move(), but writes to t.
+func (t Term) Move(row, col int, text string, a ...any) {
 	if row < 0 {
-		fmt.Fprintf(Stdout, "\x1b[%dA", -row)
+		fmt.Fprintf(t.w, "\x1b[%dA", -row)
 	} else if row > 0 {
-		fmt.Fprintf(Stdout, "\x1b[%dB", row)
+		fmt.Fprintf(t.w, "\x1b[%dB", row)
 	}
 	if col > 0 {
-		fmt.Fprintf(Stdout, "\x1b[%dC", col)
+		fmt.Fprintf(t.w, "\x1b[%dC", col)
 	} else if col < 0 {
-		fmt.Fprintf(Stdout, "\x1b[%dD", -col)
-	}
-	if text != "" {
-		if len(a) > 0 {
-			fmt.Fprintf(Stdout, text, a...)
-		} else {
-			fmt.Fprint(Stdout, text)
-		}
+		fmt.Fprintf(t.w, "\x1b[%dD", -col)
 	}
+	t.print(text, a...)
 }
 
-// Modify text, inserting or deleting lines, and print the text.
-//
-// On positive values it will insert text, moving existing text below (for
-// lines) or to the right (for characters). On negative values it will delete
-// text, moving existing text upwards (for lines) or to the left (for
-// characters). On 0 nothing is modified.
-func Modify(line, char int, text string, a ...any) {
+// Modify is like Modify(), but writes to t.
+func (t Term) Modify(line, char int, text string, a ...any) {
 	if line > 0 {
-		fmt.Fprintf(Stdout, "\x1b[%dL", line)
+		fmt.Fprintf(t.w, "\x1b[%dL", line)
 	} else if line < 0 {
-		fmt.Fprintf(Stdout, "\x1b[%dM", -line)
+		fmt.Fprintf(t.w, "\x1b[%dM", -line)
 	}
 	if char > 0 {
-		fmt.Fprintf(Stdout, "\x1b[%d@", char)
+		fmt.Fprintf(t.w, "\x1b[%d@", char)
 	} else if char < 0 {
-		fmt.Fprintf(Stdout, "\x1b[%dP", -char)
+		fmt.Fprintf(t.w, "\x1b[%dP", -char)
 	}
-	if text != "" {
-		if len(a) > 0 {
-			fmt.Fprintf(Stdout, text, a...)
-		} else {
-			fmt.Fprint(Stdout, text)
+	t.print(text, a...)
+}
+
+func (t Term) print(text string, a ...any) {
+	if text == "" {
+		return
+	}
+	if len(a) > 0 {
+		fmt.Fprintf(t.w, text, a...)
+	} else {
+		fmt.Fprint(t.w, text)
+	}
+}
+
+func max(x int, y ...int) int {
+	m := x
+	for _, yy := range y {
+		if yy > m {
+			m = yy
+		}
+	}
+	return m
+}
+
+func min(x int, y ...int) int {
+	m := x
+	for _, yy := range y {
+		if yy < m {
+			m = yy
 		}
 	}
+	return m
 }
@@ -0,0 +1,116 @@
+package zli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestErase(t *testing.T) {
+	tests := []struct {
+		erase func()
+		want  string
+	}{
+		{Erase, "aaaa\nbb\ncccc"},
+		{EraseLineStart, "aaaa\n   b\ncccc"},
+		{EraseLine, "aaaa\n\ncccc"},
+		{EraseDown, "aaaa\nbb"},
+		{EraseUp, "\n   b\ncccc"},
+		{EraseScreen, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			s := NewTestScreen(4, 3)
+			Stdout = s
+			defer func() { Stdout = os.Stdout }()
+
+			fmt.Fprint(Stdout, strings.Join([]string{"aaaa", "bbbb", "cccc"}, "\n"))
+			To(2, 3, "")
+			tt.erase()
+
+			if have := s.String(); have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerm(t *testing.T) {
+	_, _, out := Test(t)
+
+	s := NewTestScreen(10, 3)
+	term := NewTerm(s)
+	term.To(2, 3, "x=%d", 42)
+	term.Move(-1, -4, "up")
+	show := term.HideCursor()
+
+	if out.Len() != 0 {
+		t.Errorf("wrote to Stdout: %q", out.String())
+	}
+	if have := s.String(); have != "  up\n  x=42" {
+		t.Errorf("\n%s", have)
+	}
+	s.Want(t, 1, 3, "up")
+	if !s.CursorHidden() {
+		t.Error("cursor not hidden")
+	}
+	show()
+	if s.CursorHidden() {
+		t.Error("cursor still hidden")
+	}
+}
+
+func TestProgress(t *testing.T) {
+	t.Run("rate", func(t *testing.T) {
+		_, _, out := Test(t)
+
+		p := NewProgress(1)
+		for i := 1; i <= 100; i++ {
+			p.Printf("%d%%", i)
+		}
+		p.Done()
+		p.Done()
+		if have := out.String(); have != "\x1b[K\r1%\x1b[K\r100%" {
+			t.Errorf("%q", have)
+		}
+	})
+
+	t.Run("same", func(t *testing.T) {
+		_, _, out := Test(t)
+
+		p := NewProgress(0)
+		p.Printf("a")
+		p.Printf("a")
+		p.Printf("b")
+		p.Printf("b")
+		p.Done()
+		if have := out.String(); have != "\x1b[K\ra\x1b[K\rb" {
+			t.Errorf("%q", have)
+		}
+	})
+}
+
+func TestHideCursor(t *testing.T) {
+	_, _, out := Test(t)
+
+	show1 := HideCursor()
+	show2 := HideCursor()
+	show2()
+	show2()
+	if have := out.String(); have != "\x1b[?25l" {
+		t.Errorf("%q", have)
+	}
+	show1()
+	if have := out.String(); have != "\x1b[?25l\x1b[?25h" {
+		t.Errorf("%q", have)
+	}
+
+	out.Reset()
+	HideCursor()
+	RunAtExit()
+	if have := out.String(); have != "\x1b[?25l\x1b[?25h" {
+		t.Errorf("%q", have)
+	}
+}
@@ -0,0 +1,235 @@
+package zli
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// FormatDiffHeader is the formatting to apply to diff headers ("diff",
+	// "index", "---", and "+++" lines).
+	FormatDiffHeader = Bold
+
+	// FormatDiffHunk is the formatting to apply to "@@" hunk headers.
+	FormatDiffHunk = Cyan
+
+	// FormatDiffDel is the formatting to apply to removed lines.
+	FormatDiffDel = Red
+
+	// FormatDiffAdd is the formatting to apply to added lines.
+	FormatDiffAdd = Green
+
+	// FormatDiffChange is added to the formatting of the changed part of a
+	// line, if a removed line is directly followed by an added line.
+	FormatDiffChange = Reverse
+)
+
+// Diff gets a colored unified diff between old and new, with three lines of
+// context.
+//
+// This is intended for showing changes in short texts, such as "what would
+// change" in a -dry-run mode; the memory usage is len(old)*len(new) lines, so
+// it's not suitable for large inputs.
+func Diff(old, new string) string {
+	return ColorizeDiff(unifiedDiff(diffLines(splitLines(old), splitLines(new)), 3))
+}
+
+// ColorizeDiff colorizes the unified diff in text, with the FormatDiff*
+// colors.
+func ColorizeDiff(text string) string {
+	var (
+		lines  = strings.Split(text, "\n")
+		header = true
+	)
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		switch {
+		case strings.HasPrefix(l, "diff "):
+			header = true
+			lines[i] = Colorize(l, FormatDiffHeader)
+		case header && (strings.HasPrefix(l, "index ") || strings.HasPrefix(l, "--- ") || strings.HasPrefix(l, "+++ ")):
+			lines[i] = Colorize(l, FormatDiffHeader)
+		case strings.HasPrefix(l, "@@"):
+			header = false
+			lines[i] = Colorize(l, FormatDiffHunk)
+		case !header && strings.HasPrefix(l, "-"):
+			// Find a run of removed lines followed by the same number of added
+			// lines, and highlight the parts that changed.
+			del := i
+			for del < len(lines) && strings.HasPrefix(lines[del], "-") {
+				del++
+			}
+			add := del
+			for add < len(lines) && strings.HasPrefix(lines[add], "+") {
+				add++
+			}
+			if add-del != del-i {
+				for ; i < del; i++ {
+					lines[i] = Colorize(lines[i], FormatDiffDel)
+				}
+				i--
+				continue
+			}
+			for j := 0; j < del-i; j++ {
+				lines[i+j], lines[del+j] = diffChange(lines[i+j], lines[del+j])
+			}
+			i = add - 1
+		case !header && strings.HasPrefix(l, "+"):
+			lines[i] = Colorize(l, FormatDiffAdd)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func diffChange(del, add string) (string, string) {
+	a, b := []rune(del[1:]), []rune(add[1:])
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	f := func(sign string, l []rune, c Color) string {
+		s := Colorize(sign+string(l[:pre]), c)
+		if mid := l[pre : len(l)-suf]; len(mid) > 0 {
+			s += Colorize(string(mid), c|FormatDiffChange)
+		}
+		if suf > 0 {
+			s += Colorize(string(l[len(l)-suf:]), c)
+		}
+		return s
+	}
+	return f("-", a, FormatDiffDel), f("+", b, FormatDiffAdd)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+type diffOp struct {
+	kind byte // ' ', '-', or '+'
+	line string
+}
+
+// diffLines gets the edit script to transform a in to b, using the longest
+// common subsequence.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff formats ops as hunks with ctx lines of context.
+func unifiedDiff(ops []diffOp, ctx int) string {
+	var (
+		b          strings.Builder
+		oldN, newN int // Line numbers before ops[i].
+		i          int
+	)
+	for i < len(ops) {
+		for ; i < len(ops) && ops[i].kind == ' '; i++ {
+			oldN++
+			newN++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// Merge changes that are less than 2*ctx lines apart.
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			k := end
+			for k < len(ops) && ops[k].kind == ' ' {
+				k++
+			}
+			if k < len(ops) && k-end <= 2*ctx {
+				end = k
+				continue
+			}
+			break
+		}
+
+		start, stop := max(i-ctx, 0), end+ctx
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+		oldStart, newStart := oldN-(i-start), newN-(i-start)
+
+		var oldLen, newLen int
+		for _, op := range ops[start:stop] {
+			if op.kind != '+' {
+				oldLen++
+			}
+			if op.kind != '-' {
+				newLen++
+			}
+		}
+		if oldLen > 0 {
+			oldStart++
+		}
+		if newLen > 0 {
+			newStart++
+		}
+
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+		for _, op := range ops[start:stop] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			b.WriteByte('\n')
+		}
+
+		for _, op := range ops[i:stop] {
+			if op.kind != '+' {
+				oldN++
+			}
+			if op.kind != '-' {
+				newN++
+			}
+		}
+		i = stop
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package zli_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"zgo.at/zli"
+)
+
+func TestDiff(t *testing.T) {
+	defer func(c bool) { zli.WantColor = c }(zli.WantColor)
+	zli.WantColor = false
+
+	tests := []struct {
+		old, new, want string
+	}{
+		{"", "", ""},
+		{"a\nb\n", "a\nb\n", ""},
+		{"", "a\n", "@@ -0,0 +1,1 @@\n+a\n"},
+		{"a\n", "", "@@ -1,1 +0,0 @@\n-a\n"},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n",
+			"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n",
+		},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n",
+			"1\nx\n3\n4\n5\n6\n7\ny\n",
+			"@@ -1,8 +1,8 @@\n 1\n-2\n+x\n 3\n 4\n 5\n 6\n 7\n-8\n+y\n",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			have := zli.Diff(tt.old, tt.new)
+			if have != tt.want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorizeDiff(t *testing.T) {
+	defer func(c bool) { zli.WantColor = c }(zli.WantColor)
+	zli.WantColor = true
+
+	in := strings.Join([]string{
+		"diff --git a/x b/x",
+		"--- a/x",
+		"+++ b/x",
+		"@@ -1,3 +1,3 @@",
+		" same",
+		"-hello world",
+		"+hello there world",
+		"-removed",
+		"--- removed",
+		"+added",
+	}, "\n")
+	want := strings.Join([]string{
+		"\x1b[1mdiff --git a/x b/x\x1b[0m",
+		"\x1b[1m--- a/x\x1b[0m",
+		"\x1b[1m+++ b/x\x1b[0m",
+		"\x1b[36m@@ -1,3 +1,3 @@\x1b[0m",
+		" same",
+		"\x1b[31m-hello \x1b[0m\x1b[31mworld\x1b[0m",
+		"\x1b[32m+hello \x1b[0m\x1b[7;32mthere \x1b[0m\x1b[32mworld\x1b[0m",
+		"\x1b[31m-removed\x1b[0m",
+		"\x1b[31m--- removed\x1b[0m",
+		"\x1b[32m+added\x1b[0m",
+	}, "\n")
+
+	have := zli.ColorizeDiff(in)
+	if have != want {
+		t.Errorf("\nhave: %q\nwant: %q", have, want)
+	}
+}
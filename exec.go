@@ -0,0 +1,259 @@
+package zli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DryRun makes Run() print the command to Stdout instead of running it.
+var DryRun = false
+
+// ErrRun is used when a command run with Run() fails.
+type ErrRun struct {
+	Cmd      string // Command name.
+	ExitCode int    // Exit code, or -1 if the command didn't run.
+	Err      error  // Underlying error.
+}
+
+func (e ErrRun) Unwrap() error { return e.Err }
+func (e ErrRun) Error() string {
+	switch {
+	case errors.Is(e.Err, exec.ErrNotFound):
+		return fmt.Sprintf(Translate("running %q: command not found; make sure it's installed and in $PATH"), e.Cmd)
+	case e.ExitCode > -1:
+		return fmt.Sprintf(Translate("running %q: exited with code %d"), e.Cmd, e.ExitCode)
+	default:
+		return fmt.Sprintf(Translate("running %q: %s"), e.Cmd, e.Err)
+	}
+}
+
+// Run a command connected to Stdin, Stdout, and Stderr, and wait for it to
+// finish.
+//
+// It won't run anything if DryRun is set, and will print the command to Stdout
+// instead:
+//
+//	$ git commit -m 'commit message'
+//
+// Any errors are returned as an ErrRun.
+func Run(cmd string, args ...string) error {
+	if DryRun {
+		fmt.Fprintln(Stdout, "$", shellQuote(append([]string{cmd}, args...)))
+		return nil
+	}
+
+	c := exec.Command(cmd, args...)
+	c.Stdin, c.Stdout, c.Stderr = Stdin, Stdout, Stderr
+	err := c.Run()
+	if err != nil {
+		code := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+		return ErrRun{Cmd: cmd, ExitCode: code, Err: err}
+	}
+	return nil
+}
+
+func shellQuote(args []string) string {
+	q := make([]string, 0, len(args))
+	for _, a := range args {
+		if a != "" && strings.IndexFunc(a, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+		}) == -1 {
+			q = append(q, a)
+			continue
+		}
+		q = append(q, "'"+strings.ReplaceAll(a, "'", `'\''`)+"'")
+	}
+	return strings.Join(q, " ")
+}
+
+// ErrLookPath is used when LookPath() can't find an executable.
+type ErrLookPath struct {
+	Name    string   // Executable name.
+	Hint    string   // Installation hint.
+	Similar []string // Executables in PATH with a similar name.
+}
+
+func (e ErrLookPath) Error() string {
+	s := fmt.Sprintf(Translate("%q not found in $PATH"), e.Name)
+	if len(e.Similar) > 0 {
+		s += fmt.Sprintf(Translate(`; did you mean "%s"?`), strings.Join(e.Similar, `", "`))
+	}
+	if e.Hint != "" {
+		s += "\n" + e.Hint
+	}
+	return s
+}
+
+// LookPath searches for an executable in PATH, like exec.LookPath().
+//
+// If it can't be found it returns an ErrLookPath, which lists executables in
+// PATH with a similar name, as well as the hint. The hint can be used to tell
+// users how to install it:
+//
+//	_, err := zli.LookPath("jq", "install it from https://jqlang.github.io/jq")
+//	zli.F(err)
+func LookPath(name, hint string) (string, error) {
+	p, err := exec.LookPath(name)
+	if err == nil {
+		return p, nil
+	}
+	if !errors.Is(err, exec.ErrNotFound) {
+		return "", err
+	}
+	return "", ErrLookPath{Name: name, Hint: hint, Similar: similarInPath(name)}
+}
+
+// ErrVersion is used when CheckVersion() finds a version that's too old.
+type ErrVersion struct {
+	Path, Have, Want string
+}
+
+func (e ErrVersion) Error() string {
+	return fmt.Sprintf(Translate("%s: version %s is too old; need at least %s"), e.Path, e.Have, e.Want)
+}
+
+// CheckVersion runs "path --version" and checks that the first version number
+// in the output is at least min. It returns the version it found.
+//
+// Versions are compared per number, so "1.10" is larger than "1.9". A leading
+// "v" is ignored.
+func CheckVersion(path, min string) (string, error) {
+	out, err := exec.Command(path, "--version").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("zli.CheckVersion: running %s --version: %w", path, err)
+	}
+	have := reVersion.FindString(string(out))
+	if have == "" {
+		return "", fmt.Errorf("zli.CheckVersion: no version number in output of %s --version", path)
+	}
+	if compareVersion(have, min) < 0 {
+		return have, ErrVersion{Path: path, Have: have, Want: min}
+	}
+	return have, nil
+}
+
+var reVersion = regexp.MustCompile(`\d+(?:\.\d+)+|\d+`)
+
+// compareVersion compares two versions in the form of "v1.2.3", returning -1
+// if a is lower than b, 1 if a is higher than b, and 0 if they're equal.
+//
+// A pre-release version ("v1.2.3-rc1") is lower than the version without it,
+// and build metadata ("v1.2.3+build") is ignored.
+func compareVersion(a, b string) int {
+	if i := strings.IndexByte(a, '+'); i > -1 {
+		a = a[:i]
+	}
+	if i := strings.IndexByte(b, '+'); i > -1 {
+		b = b[:i]
+	}
+	ap, bp := trimPrerelease(a), trimPrerelease(b)
+	as := strings.Split(strings.TrimPrefix(ap, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(bp, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	switch apre, bpre := a[len(ap):], b[len(bp):]; {
+	case apre == bpre:
+		return 0
+	case apre == "":
+		return 1
+	case bpre == "":
+		return -1
+	default:
+		return strings.Compare(apre, bpre)
+	}
+}
+
+// trimPrerelease removes any "-pre" or "+build" suffix from a version.
+func trimPrerelease(v string) string {
+	if i := strings.IndexAny(v, "-+"); i > -1 {
+		return v[:i]
+	}
+	return v
+}
+
+func similarInPath(name string) []string {
+	type match struct {
+		name string
+		dist int
+	}
+	var (
+		found []match
+		seen  = make(map[string]struct{})
+	)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		ls, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range ls {
+			n := f.Name()
+			if _, ok := seen[n]; ok || f.IsDir() {
+				continue
+			}
+			seen[n] = struct{}{}
+			if d := levenshtein(name, n); d <= max(1, len(name)/4) {
+				found = append(found, match{n, d})
+			}
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].dist == found[j].dist {
+			return found[i].name < found[j].name
+		}
+		return found[i].dist < found[j].dist
+	})
+	if len(found) > 5 {
+		found = found[:5]
+	}
+	names := make([]string, 0, len(found))
+	for _, f := range found {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
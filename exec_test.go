@@ -0,0 +1,99 @@
+package zli_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"zgo.at/zli"
+)
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		_, in, out := zli.Test(t)
+		in.WriteString("hello")
+		err := zli.Run("sh", "-c", "cat; echo ' world'")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != "hello world\n" {
+			t.Errorf("wrong output: %q", out.String())
+		}
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		zli.Test(t)
+		err := zli.Run("sh", "-c", "exit 3")
+		var runErr zli.ErrRun
+		if !errors.As(err, &runErr) || runErr.ExitCode != 3 {
+			t.Fatalf("wrong error: %#v", err)
+		}
+		if err.Error() != `running "sh": exited with code 3` {
+			t.Errorf("wrong error: %q", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		zli.Test(t)
+		err := zli.Run("zli-nonexistent-command")
+		want := `running "zli-nonexistent-command": command not found; make sure it's installed and in $PATH`
+		if err == nil || err.Error() != want {
+			t.Errorf("wrong error: %q", err)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		_, _, out := zli.Test(t)
+		zli.DryRun = true
+		defer func() { zli.DryRun = false }()
+
+		err := zli.Run("git", "commit", "-m", "it's a message", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `$ git commit -m 'it'\''s a message' ''` + "\n"
+		if out.String() != want {
+			t.Errorf("\nhave: %q\nwant: %q", out.String(), want)
+		}
+	})
+}
+
+func TestLookPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "mytool"), []byte("#!/bin/sh\necho 'mytool version 1.9.2'\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	_, err = zli.LookPath("mytol", "install it with: go install mytool")
+	want := `"mytol" not found in $PATH; did you mean "mytool"?` + "\ninstall it with: go install mytool"
+	if err == nil || err.Error() != want {
+		t.Errorf("\nhave: %v\nwant: %v", err, want)
+	}
+
+	p, err := zli.LookPath("mytool", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	have, err := zli.CheckVersion(p, "v1.9")
+	if err != nil || have != "1.9.2" {
+		t.Errorf("have: %q; err: %v", have, err)
+	}
+	_, err = zli.CheckVersion(p, "1.10")
+	want = p + ": version 1.9.2 is too old; need at least 1.10"
+	if err == nil || err.Error() != want {
+		t.Errorf("\nhave: %v\nwant: %v", err, want)
+	}
+}
@@ -1,14 +1,19 @@
 package zli
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime/pprof"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 type (
@@ -26,6 +31,19 @@ type (
 		kind string
 	}
 
+	// ErrFlagEnum is used when the value for an Enum() flag isn't one of the
+	// allowed values. Value is empty for Secret() flags.
+	ErrFlagEnum struct {
+		Flag    string
+		Value   string
+		Allowed []string
+		secret  bool
+	}
+
+	// ErrFlagConflict is used when two flags or a command and flag are used
+	// together, but can't be; see Flags.Conflicts().
+	ErrFlagConflict struct{ A, B string }
+
 	// ErrPositional is used when there are too few or too many positional
 	// arguments.
 	ErrPositional struct {
@@ -35,26 +53,45 @@ type (
 
 func (e ErrFlagInvalid) Unwrap() error { return e.err }
 func (e ErrFlagInvalid) Error() string {
-	return fmt.Sprintf("%s: %s (must be a %s)", e.flag, e.err, e.kind)
+	return fmt.Sprintf(Translate("%s: %s (must be a %s)"), e.flag, e.err, Translate(e.kind))
+}
+func (e ErrFlagEnum) Error() string {
+	if e.secret {
+		return fmt.Sprintf(Translate(`%s: invalid value; must be one of: "%s"`),
+			e.Flag, strings.Join(e.Allowed, `", "`))
+	}
+	return fmt.Sprintf(Translate(`%s: invalid value %q; must be one of: "%s"`),
+		e.Flag, e.Value, strings.Join(e.Allowed, `", "`))
+}
+func (e ErrFlagConflict) Error() string {
+	if !strings.HasPrefix(e.A, "-") {
+		return fmt.Sprintf(Translate("%s can't be used with %s"), e.B, e.A)
+	}
+	if !strings.HasPrefix(e.B, "-") {
+		return fmt.Sprintf(Translate("%s can't be used with %s"), e.A, e.B)
+	}
+	return fmt.Sprintf(Translate("%s and %s can't be used together"), e.A, e.B)
+}
+func (e ErrFlagUnknown) Error() string { return fmt.Sprintf(Translate("unknown flag: %q"), e.flag) }
+func (e ErrFlagDouble) Error() string {
+	return fmt.Sprintf(Translate("flag given more than once: %q"), e.flag)
 }
-func (e ErrFlagUnknown) Error() string { return fmt.Sprintf("unknown flag: %q", e.flag) }
-func (e ErrFlagDouble) Error() string  { return fmt.Sprintf("flag given more than once: %q", e.flag) }
 func (e ErrPositional) Error() string {
 	pl := func(n int) string {
 		if n == 1 {
-			return "argument"
+			return Translate("argument")
 		}
-		return "arguments"
+		return Translate("arguments")
 	}
 	switch {
 	case e.min == e.max:
-		return fmt.Sprintf("exactly %d positional %s required, but %d given", e.min, pl(e.min), e.n)
+		return fmt.Sprintf(Translate("exactly %d positional %s required, but %d given"), e.min, pl(e.min), e.n)
 	case e.max == 0 && e.min > 0:
-		return fmt.Sprintf("at least %d positional %s required, but %d given", e.min, pl(e.min), e.n)
+		return fmt.Sprintf(Translate("at least %d positional %s required, but %d given"), e.min, pl(e.min), e.n)
 	case e.min == 0 && e.max > 0:
-		return fmt.Sprintf("at most %d positional %s accepted, but %d given", e.max, pl(e.max), e.n)
+		return fmt.Sprintf(Translate("at most %d positional %s accepted, but %d given"), e.max, pl(e.max), e.n)
 	default:
-		return fmt.Sprintf("between %d and %d positional arguments accepted, but %d given", e.min, e.max, e.n)
+		return fmt.Sprintf(Translate("between %d and %d positional arguments accepted, but %d given"), e.min, e.max, e.n)
 	}
 }
 
@@ -65,11 +102,14 @@ func (e ErrPositional) Error() string {
 //   - Flags start with one or more '-'s; '-a' and '--a' are identical, as are
 //     '-long' and '--long'.
 //
-//   - Flags are separated with arguments by one space or '='. This is required:
-//     '-vVALUE' is invalid; you must use '-v VALUE' or '-v=VALUE'.
+//   - Flags are separated with arguments by one space or '='. Single-letter
+//     flags can also have the value directly after it: '-vVALUE' is identical
+//     to '-v=VALUE'.
 //
 //   - Single-letter flags can be grouped; '-ab' is identical to '-a -b', and
-//     '-ab VAL' is identical to '-a -b VAL'. "Long" flags cannot be grouped.
+//     '-ab VAL' is identical to '-a -b VAL'. Everything after a flag that
+//     accepts a value is used as the value: '-abVAL' is identical to
+//     '-a -b=VAL'. "Long" flags cannot be grouped.
 //
 //   - Long flag names take precedence over single-letter ones, e.g. if you
 //     define the flags '-long', '-l', '-o', '-n', and '-g' then '-long' will be
@@ -82,13 +122,35 @@ type Flags struct {
 	Args    []string // List of arguments, after parsing this will be reduces to non-flags.
 
 	flags            []flagValue
+	names            map[string]flagValue // Set in Parse().
 	optional         bool
+	hidden           bool
+	group            string
+	command          string            // Set by ShiftCommand()
+	conflicts        [][2]string       // Set by Conflicts()
+	deprecated       map[string]string // Set by Deprecated()
+	deprecatedUsed   []string
 	cpuProf, memProf flagString
+	help, version    flagBool // Set by AutoHelp() and AutoVersion().
 }
 
 type flagValue struct {
 	names []string
 	value any
+	meta  *flagMeta
+}
+
+// flagMeta is shared between all copies of a flag, so that methods like
+// Secret() can modify it after the flag is added.
+type flagMeta struct {
+	secret      bool
+	hideDefault bool
+	hidden      bool
+	group       string
+	help        string // Set by Help()
+	placeholder string // Set by Help()
+	def         any    // Pointer to copy of the default value.
+	name        string // Name as given on the CLI.
 }
 
 type setter interface{ Set() bool }
@@ -125,10 +187,12 @@ type (
 	}
 )
 
-func (e ErrCommandNoneGiven) Error() string { return "no command given" }
-func (e ErrCommandUnknown) Error() string   { return fmt.Sprintf("unknown command: %q", string(e)) }
+func (e ErrCommandNoneGiven) Error() string { return Translate("no command given") }
+func (e ErrCommandUnknown) Error() string {
+	return fmt.Sprintf(Translate("unknown command: %q"), string(e))
+}
 func (e ErrCommandAmbiguous) Error() string {
-	return fmt.Sprintf(`ambigious command: %q; matches: "%s"`, e.Cmd, strings.Join(e.Opts, `", "`))
+	return fmt.Sprintf(Translate(`ambigious command: %q; matches: "%s"`), e.Cmd, strings.Join(e.Opts, `", "`))
 }
 
 // ShiftCommand shifts the first non-flag value from the argument list.
@@ -150,6 +214,14 @@ func (e ErrCommandAmbiguous) Error() string {
 // Return [ErrCommandNoneGiven] if there is no command, and [ErrCommandUnknown]
 // if the command is not found.
 func (f *Flags) ShiftCommand(cmds ...string) (string, error) {
+	cmd, err := f.shiftCommand(cmds...)
+	if err == nil {
+		f.command = cmd
+	}
+	return cmd, err
+}
+
+func (f *Flags) shiftCommand(cmds ...string) (string, error) {
 	var (
 		pushback []string
 		cmd      string
@@ -197,6 +269,65 @@ func (f *Flags) ShiftCommand(cmds ...string) (string, error) {
 	}
 }
 
+// CommandsUsage generates a "Commands:" section for a usage message from the
+// same list of commands passed to ShiftCommand(), so that the usage is always
+// in sync with the commands that are accepted.
+//
+// Commands are sorted, aliases are listed after the command, and desc has an
+// optional one-line description for every command:
+//
+//	cmds := []string{"help", "commit", "ci=commit"}
+//	zli.CommandsUsage(cmds, map[string]string{
+//	    "help":   "Show this help.",
+//	    "commit": "Record changes.",
+//	})
+//
+// Will return:
+//
+//	Commands:
+//	    commit, ci    Record changes.
+//	    help          Show this help.
+func CommandsUsage(cmds []string, desc map[string]string) string {
+	var (
+		names   []string
+		seen    = make(map[string]bool)
+		aliases = make(map[string][]string)
+	)
+	for _, c := range cmds {
+		if i := strings.IndexRune(c, '='); i > -1 {
+			alias := c[:i]
+			c = c[i+1:]
+			aliases[c] = append(aliases[c], alias)
+		}
+		if !seen[c] {
+			seen[c] = true
+			names = append(names, c)
+		}
+	}
+	sort.Strings(names)
+
+	var (
+		cols  = make([]string, len(names))
+		width int
+	)
+	for i, n := range names {
+		sort.Strings(aliases[n])
+		cols[i] = strings.Join(append([]string{n}, aliases[n]...), ", ")
+		width = max(width, utf8.RuneCountInString(cols[i]))
+	}
+
+	var b strings.Builder
+	b.WriteString(Translate("Commands:") + "\n")
+	for i, n := range names {
+		b.WriteString("    " + cols[i])
+		if d := desc[n]; d != "" {
+			b.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(cols[i])+4) + d)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
 var (
 	// AllowUnknown indicates that unknown flags are not an error; unknown flags
 	// are added to the Args list.
@@ -214,6 +345,21 @@ var (
 	//     }
 	AllowUnknown = func() parseOpt { return func(o *parseOpts) { o.allowUnknown = true } }
 
+	// CollectUnknown is like AllowUnknown, but unknown flags are appended to
+	// unknown instead of Args, in the order they were given. This is useful for
+	// wrapper programs that forward flags to another program:
+	//
+	//     var forward []string
+	//     f.Parse(zli.CollectUnknown(&forward))
+	//     zli.Run("ls", forward...)
+	//
+	// Flags are added verbatim, so a value given as "-flag=value" is included,
+	// but for "-flag value" only "-flag" is collected and "value" is added to
+	// Args, as there's no way to know if an unknown flag accepts a value.
+	CollectUnknown = func(unknown *[]string) parseOpt {
+		return func(o *parseOpts) { o.allowUnknown, o.unknown = true, unknown }
+	}
+
 	// AllowMultiple indicates that specifying a flag more than once is not an
 	// error.
 	//
@@ -224,8 +370,58 @@ var (
 	//   % prog -w 50 -w 90
 	//
 	// "-w" will have the value of "80".
+	//
+	// An Optional() flag without a value only marks the flag as set, and
+	// doesn't reset a value given earlier; for:
+	//
+	//   % prog -w=50 -w
+	//
+	// "-w" will have the value of "50".
 	AllowMultiple = func() parseOpt { return func(o *parseOpts) { o.allowMultiple = true } }
 
+	// OptionalEquals indicates that the value for Optional() flags must be given
+	// with a '=' (or directly after a single-letter flag), and that the next
+	// argument is never used as the value:
+	//
+	//   % prog -color=never       -color is "never"
+	//   % prog -color never       -color is set without value; "never" is a positional
+	//   % prog -c never           Same
+	//   % prog -cnever            -c is "never"
+	//
+	// Without this the next argument is used as the value if it doesn't start
+	// with a '-', which may be ambiguous.
+	OptionalEquals = func() parseOpt { return func(o *parseOpts) { o.optionalEquals = true } }
+
+	// BoolValues indicates that Bool() flags accept a value with '=', as
+	// accepted by strconv.ParseBool():
+	//
+	//   % prog -verbose=false
+	//   % prog -verbose=1
+	//
+	// Without this the value is ignored and the flag is always true.
+	BoolValues = func() parseOpt { return func(o *parseOpts) { o.boolValues = true } }
+
+	// SlashFlags indicates that flags may also start with a '/', as is common
+	// on Windows. The value can be given with a ':' or '=' or as the next
+	// argument:
+	//
+	//   % prog /v /out:file.txt /in file.txt
+	//
+	// Arguments starting with a '/' that aren't a known flag are treated as a
+	// positional argument, so paths such as "/etc/passwd" still work.
+	SlashFlags = func() parseOpt { return func(o *parseOpts) { o.slash = true } }
+
+	// StrictGNU parses flags like GNU getopt_long(): flags with long names must
+	// start with '--', and a single '-' is always one or more single-letter
+	// flags:
+	//
+	//   % prog -verbose      Same as -v -e -r -b -o -s -e
+	//   % prog --verbose     Long flag
+	//
+	// The default is to accept both '-' and '--' for long names, which is
+	// similar to getopt_long_only().
+	StrictGNU = func() parseOpt { return func(o *parseOpts) { o.strictGNU = true } }
+
 	// Positional sets the lower and upper bounds for the number of positional
 	// arguments.
 	//
@@ -238,33 +434,291 @@ var (
 
 	// NoPositional is a shortcut for Positional(-1, 0)
 	NoPositional = func() parseOpt { return func(o *parseOpts) { o.pos = [2]int{-1, -1} } }
+
+	// AskMissing asks for missing positional arguments if there are fewer than
+	// the minimum set with Positional() and the program is run interactively
+	// (see Interactive()), instead of returning an error:
+	//
+	//     f.Parse(zli.Positional(2, 2), zli.AskMissing("Source", "Destination"))
+	//
+	// This asks "Source: " and "Destination: " for the missing arguments, or
+	// "Argument 1: ", "Argument 2: ", etc. if there are no names. Empty answers
+	// are not accepted. Nothing is asked if the program isn't interactive, so
+	// scripts still get an error.
+	AskMissing = func(names ...string) parseOpt {
+		return func(o *parseOpts) { o.askMissing, o.askNames = true, names }
+	}
+
+	// AutoHelp adds the -h and -help flags. If either is given the usage is
+	// printed to Stdout and the program exits with Exit(0), even if there are
+	// other errors such as unknown flags, in any order:
+	//
+	//   err := f.Parse(zli.AutoHelp(usage))
+	//   zli.F(err)
+	//
+	// The flags aren't added if they already exist, and aren't listed in
+	// Usage().
+	AutoHelp = func(usage string) parseOpt { return func(o *parseOpts) { o.help = &usage } }
+
+	// AutoVersion adds the -version flag. If it's given the output of
+	// PrintVersion(false) is printed to Stdout and the program exits with
+	// Exit(0), like AutoHelp().
+	AutoVersion = func() parseOpt { return func(o *parseOpts) { o.version = true } }
 )
 
 type (
 	parseOpts struct {
-		allowUnknown  bool
-		allowMultiple bool
-		pos           [2]int
+		allowUnknown   bool
+		unknown        *[]string
+		allowMultiple  bool
+		optionalEquals bool
+		slash          bool
+		boolValues     bool
+		strictGNU      bool
+		pos            [2]int
+		askMissing     bool
+		askNames       []string
+		help           *string
+		version        bool
 	}
 	parseOpt func(*parseOpts)
 )
 
+// addAuto adds the -h, -help, and -version flags, unless they already exist.
+func (f *Flags) addAuto(help, version bool) {
+	exists := func(name string) bool {
+		for _, fl := range f.flags {
+			for _, n := range fl.names {
+				if n == name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	group := f.group
+	f.group = ""
+	if help && f.help.v == nil {
+		var names []string
+		for _, n := range []string{"h", "help"} {
+			if !exists(n) {
+				names = append(names, n)
+			}
+		}
+		if len(names) > 0 {
+			f.help = f.Hidden().Bool(false, names[0], names[1:]...)
+		}
+	}
+	if version && f.version.v == nil && !exists("version") {
+		f.version = f.Hidden().Bool(false, "version")
+	}
+	f.group = group
+}
+
+// autoGiven reports if the flag added by addAuto() was given. Parse() may
+// return an error before it gets to the flag, so also look for it in f.Args if
+// there was an error.
+func (f *Flags) autoGiven(flag flagBool, err error) bool {
+	if flag.v == nil {
+		return false
+	}
+	if flag.Bool() {
+		return true
+	}
+	if err == nil {
+		return false
+	}
+	for _, a := range f.Args {
+		if a == "--" {
+			break
+		}
+		if fl, ok := f.match(a); ok && strings.HasPrefix(a, "-") && fl.meta == flag.m {
+			return true
+		}
+	}
+	return false
+}
+
+// index all flag names.
+func (f *Flags) index() {
+	// Always include CPU/memory profile; doesn't actually do anything until
+	// Flags.Profile() is called.
+	if f.cpuProf.v == nil {
+		group := f.group
+		f.group = ""
+		f.cpuProf = f.Hidden().String("", "cpuprofile", "cpu-profile")
+		f.memProf = f.Hidden().String("", "memprofile", "mem-profile")
+		f.group = group
+	}
+
+	// Index all names, so we don't need to loop over all flags for every
+	// argument. The first flag with a name wins if there are duplicates.
+	f.names = make(map[string]flagValue, len(f.flags)*2)
+	for _, flag := range f.flags {
+		for _, name := range flag.names {
+			if _, ok := f.names[name]; !ok {
+				f.names[name] = flag
+			}
+		}
+	}
+}
+
+// ParseGlobal parses the flags before the first positional argument, which is
+// usually a command, and leaves the command and everything after it in f.Args
+// to be parsed later with Parse(). This makes it easy to have global flags and
+// flags for specific commands:
+//
+//	f := zli.NewFlags(os.Args)
+//	verbose := f.Bool(false, "v")
+//	zli.F(f.ParseGlobal())
+//
+//	switch cmd, _ := f.ShiftCommand("serve", "migrate"); cmd {
+//	case "serve":
+//	    port := f.Int(8080, "port")
+//	    zli.F(f.Parse())
+//	}
+//
+// The rules are:
+//
+//   - Flags before the command must be defined before ParseGlobal(); unknown
+//     flags are an error unless AllowUnknown() is used, in which case they're
+//     moved after the command in f.Args, so they're parsed by the next Parse().
+//
+//   - Optional() flags never use the next argument as the value, as in
+//     OptionalEquals(), as it can't be distinguished from the command.
+//
+//   - Flags after the command are parsed by the next Parse(), which accepts
+//     both the global flags and the flags defined after ParseGlobal(); so
+//     "prog -v serve" and "prog serve -v" are identical.
+//
+//   - A "--" before the command ends the global flags, and is removed from
+//     f.Args; it can be used if the command starts with a "-".
+func (f *Flags) ParseGlobal(opts ...parseOpt) error {
+	var opt parseOpts
+	for _, o := range opts {
+		o(&opt)
+	}
+	f.index()
+
+	var (
+		i    = f.commandIndex(opt)
+		rest = f.Args[i:]
+	)
+	if i < len(f.Args) && f.Args[i] == "--" {
+		rest = rest[1:]
+	}
+	f.Args = f.Args[:i]
+
+	err := f.Parse(append(opts, OptionalEquals())...)
+
+	// Anything left are unknown flags with AllowUnknown(); add them after the
+	// command so that Shift() and ShiftCommand() still get the command, and
+	// the next Parse() can parse them as flags for the command.
+	if len(rest) > 0 {
+		f.Args = append(append([]string{rest[0]}, f.Args...), rest[1:]...)
+	} else {
+		f.Args = append(f.Args, rest...)
+	}
+	return err
+}
+
+// commandIndex gets the index of the first positional argument or "--".
+func (f *Flags) commandIndex(opt parseOpts) int {
+	for i := 0; i < len(f.Args); i++ {
+		a := f.Args[i]
+		if opt.slash && len(a) > 1 && a[0] == '/' {
+			if fl := f.slashFlag(a); fl != "" {
+				a = fl
+			}
+		}
+		if a == "" || a == "-" || a == "--" || a[0] != '-' {
+			return i
+		}
+		if strings.ContainsRune(a, '=') {
+			continue
+		}
+
+		/// "-flag value"
+		if flag, ok := f.match(a); ok {
+			if acceptsValue(flag) && !isOptional(flag) {
+				i++
+			}
+			continue
+		}
+
+		/// "-abc value"; the value is only the next argument if the last
+		/// letter accepts a value.
+		name := a[1:]
+		for j, r := range name {
+			flag, ok := f.match(string(r))
+			if !ok {
+				break
+			}
+			if acceptsValue(flag) {
+				if j+utf8.RuneLen(r) == len(name) && !isOptional(flag) {
+					i++
+				}
+				break
+			}
+		}
+	}
+	return len(f.Args)
+}
+
 // Parse the set of flags in f.Args.
 func (f *Flags) Parse(opts ...parseOpt) error {
 	var opt parseOpts
 	for _, o := range opts {
 		o(&opt)
 	}
+	if opt.help == nil && !opt.version {
+		return f.parse(opt)
+	}
 
-	// Always include CPU/memory profile; doesn't actually do anything until
-	// Flags.Profile() is called.
-	f.cpuProf = f.String("", "cpuprofile", "cpu-profile")
-	f.memProf = f.String("", "memprofile", "mem-profile")
+	f.addAuto(opt.help != nil, opt.version)
+	err := f.parse(opt)
+	switch {
+	case opt.help != nil && f.autoGiven(f.help, err):
+		fmt.Fprint(Stdout, *opt.help)
+		Exit(0)
+	case opt.version && f.autoGiven(f.version, err):
+		PrintVersion(false)
+		Exit(0)
+	}
+	return err
+}
+
+func (f *Flags) parse(opt parseOpts) error {
+	f.index()
 
 	// Modify f.Args to split out grouped boolean values: "prog -ab" becomes
 	// "prog -a -b"
-	args := make([]string, 0, len(f.Args))
+	//
+	// Every letter in a group may become a new argument, so make sure there's
+	// enough space for that.
+	n := len(f.Args)
 	for _, arg := range f.Args {
+		if len(arg) > 2 && arg[0] == '-' && arg[1] != '-' {
+			n += len(arg) - 2
+		}
+	}
+	args := make([]string, 0, n)
+	for i, arg := range f.Args {
+		/// Everything after "--" is a positional argument.
+		if arg == "--" {
+			args = append(args, f.Args[i:]...)
+			break
+		}
+
+		/// Rewrite "/flag:value" to "-flag=value".
+		if opt.slash && len(arg) > 1 && arg[0] == '/' {
+			if fl := f.slashFlag(arg); fl != "" {
+				args = append(args, fl)
+				continue
+			}
+		}
+
 		/// Skip non-flags.
 		if !strings.HasPrefix(arg, "-") || arg == "-" {
 			args = append(args, arg)
@@ -273,7 +727,7 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 
 		/// Try to match the full string first, e.g. "-help", "-color".
 		_, ok := f.match(arg)
-		if ok {
+		if ok && !(opt.strictGNU && singleDashLong(arg)) {
 			args = append(args, arg)
 			continue
 		}
@@ -285,12 +739,12 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 
 		/// No match for the long string: test each individual letter.
 		var (
-			split    = strings.Split(arg[1:], "")
+			name     = arg[1:]
 			found    = true
 			shortarg = -1
 		)
-		for i, s := range split {
-			val, ok := f.match(s)
+		for i, r := range name {
+			val, ok := f.match(name[i : i+utf8.RuneLen(r)])
 			if !ok {
 				found = false
 				break
@@ -301,7 +755,7 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 			///   cut -f1
 			///   cut -wf1
 			if acceptsValue(val) {
-				shortarg = i + 1
+				shortarg = i + utf8.RuneLen(r)
 				break
 			}
 		}
@@ -310,20 +764,39 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 			args = append(args, arg)
 			continue
 		}
-		for i, s := range split {
-			if i == shortarg {
-				args = append(args, s)
-			} else if shortarg > -1 && i > shortarg {
-				args[len(args)-1] += s
+
+		/// Write all flags as "-a-b-c" in one string and slice that, instead
+		/// of allocating a new string for every flag.
+		flags := name
+		if shortarg > -1 {
+			flags = name[:shortarg]
+		}
+		var b strings.Builder
+		b.Grow(len(flags) * 2)
+		for _, r := range flags {
+			b.WriteByte('-')
+			b.WriteRune(r)
+		}
+		all := b.String()
+		for i := 0; i < len(all); {
+			_, n := utf8.DecodeRuneInString(all[i+1:])
+			args = append(args, all[i:i+1+n])
+			i += 1 + n
+		}
+		/// Attach the value with a "=", so that it's always used as the value:
+		/// "-o-x" is "-o=-x" and not "-o -x".
+		if shortarg > -1 && shortarg < len(name) {
+			if name[shortarg] == '=' {
+				args[len(args)-1] += name[shortarg:]
 			} else {
-				args = append(args, "-"+s)
+				args[len(args)-1] += "=" + name[shortarg:]
 			}
 		}
 	}
 	f.Args = args
 
 	var (
-		p    []string
+		p    = make([]string, 0, len(f.Args))
 		skip bool
 	)
 	for i, a := range f.Args {
@@ -343,22 +816,52 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 		}
 
 		flag, ok := f.match(a)
+		if ok && opt.strictGNU && singleDashLong(a) {
+			ok = false
+		}
 		if !ok {
+			if opt.unknown != nil {
+				*opt.unknown = append(*opt.unknown, a)
+				continue
+			}
 			if opt.allowUnknown {
 				p = append(p, a)
 				continue
 			}
 			return &ErrFlagUnknown{a}
 		}
+		flag.meta.name = a
+		if j := strings.IndexByte(a, '='); j > -1 {
+			flag.meta.name = a[:j]
+			// Never include the value of secret flags in errors: "-token=x"
+			// becomes "-token".
+			if flag.meta.secret {
+				a = a[:j]
+			}
+		}
+		if msg, ok := f.deprecated[strings.TrimLeft(flag.meta.name, "-")]; ok {
+			f.deprecatedUsed = append(f.deprecatedUsed, flag.meta.name)
+			if msg == "" {
+				Warnf(Translate("%s is deprecated"), flag.meta.name)
+			} else {
+				Warnf(Translate("%s is deprecated; %s"), flag.meta.name, msg)
+			}
+		}
 
-		var err error
+		var (
+			err            error
+			optionalEquals = opt.optionalEquals
+		)
 		next := func(opt bool) (string, bool, bool) {
 			if j := strings.IndexByte(f.Args[i], '='); j > -1 {
 				return f.Args[i][j+1:], true, true
 			}
+			if opt && optionalEquals {
+				return "", true, false
+			}
 			if i >= len(f.Args)-1 {
 				if !opt {
-					err = fmt.Errorf("needs an argument")
+					err = errors.New(Translate("needs an argument"))
 					return "", false, false
 				}
 				return "", true, false
@@ -395,11 +898,34 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 		case flagBool:
 			*v.s = true
 			*v.v = true
+			// Read the value from f.Args[i], as a has the value removed for
+			// secret flags.
+			if j := strings.IndexByte(f.Args[i], '='); j > -1 && opt.boolValues {
+				x, err := strconv.ParseBool(f.Args[i][j+1:])
+				if err != nil {
+					if nErr := errors.Unwrap(err); nErr != nil {
+						err = nErr
+					}
+					return ErrFlagInvalid{a, err, "boolean"}
+				}
+				*v.v = x
+			}
 		case flagString:
 			val, *v.s, hasValue = next(v.o)
 			if hasValue {
 				*v.v = val
 			}
+		case flagEnum:
+			val, *v.s, hasValue = next(v.o)
+			if hasValue {
+				if !v.valid(val) {
+					if flag.meta.secret {
+						return ErrFlagEnum{Flag: a, Allowed: v.allowed, secret: true}
+					}
+					return ErrFlagEnum{Flag: a, Value: val, Allowed: v.allowed}
+				}
+				*v.v = val
+			}
 		case flagInt:
 			val, *v.s, hasValue = next(v.o)
 			if hasValue {
@@ -412,6 +938,18 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 				}
 				*v.v = int(x)
 			}
+		case flagInt32:
+			val, *v.s, hasValue = next(v.o)
+			if hasValue {
+				x, err := strconv.ParseInt(val, 0, 32)
+				if err != nil {
+					if nErr := errors.Unwrap(err); nErr != nil {
+						err = nErr
+					}
+					return ErrFlagInvalid{a, err, "number"}
+				}
+				*v.v = int32(x)
+			}
 		case flagInt64:
 			val, *v.s, hasValue = next(v.o)
 			if hasValue {
@@ -424,6 +962,18 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 				}
 				*v.v = x
 			}
+		case flagByteSize:
+			val, *v.s, hasValue = next(v.o)
+			if hasValue {
+				x, err := ParseBytes(val)
+				if err != nil {
+					if nErr := errors.Unwrap(err); nErr != nil {
+						err = nErr
+					}
+					return ErrFlagInvalid{a, err, "size"}
+				}
+				*v.v = x
+			}
 		case flagFloat64:
 			val, *v.s, hasValue = next(v.o)
 			if hasValue {
@@ -436,9 +986,33 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 				}
 				*v.v = x
 			}
+		case flagVar:
+			val, *v.s, hasValue = next(v.o)
+			if hasValue {
+				if err := v.v.UnmarshalText([]byte(val)); err != nil {
+					// The error may include the value.
+					if flag.meta.secret {
+						return fmt.Errorf(Translate("%s: invalid value"), a)
+					}
+					return fmt.Errorf("%s: %w", a, err)
+				}
+			}
 		case flagIntCounter:
 			*v.s = true
-			*v.v++
+			// "-v=3" sets the value directly. Read it from f.Args[i], as a
+			// has the value removed for secret flags.
+			if j := strings.IndexByte(f.Args[i], '='); j > -1 {
+				x, err := strconv.ParseInt(f.Args[i][j+1:], 0, 64)
+				if err != nil {
+					if nErr := errors.Unwrap(err); nErr != nil {
+						err = nErr
+					}
+					return ErrFlagInvalid{a, err, "number"}
+				}
+				*v.v = int(x)
+			} else {
+				*v.v++
+			}
 		case flagStringList:
 			if !*v.s {
 				*v.v = nil
@@ -472,6 +1046,18 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 		}
 	}
 
+	for _, c := range f.conflicts {
+		a, okA := f.used(c[0])
+		b, okB := f.used(c[1])
+		if okA && okB {
+			return ErrFlagConflict{A: a, B: b}
+		}
+	}
+
+	if opt.askMissing && len(p) < opt.pos[0] && Interactive() {
+		p = askMissing(p, opt.pos[0], opt.askNames)
+	}
+
 	if (opt.pos[0] > 0 && len(p) < opt.pos[0]) ||
 		(opt.pos[1] > 0 && len(p) > opt.pos[1]) ||
 		opt.pos[0] == -1 && len(p) > 0 {
@@ -481,6 +1067,66 @@ func (f *Flags) Parse(opts ...parseOpt) error {
 	return nil
 }
 
+// askMissing asks for positional arguments until there are min arguments, or
+// until reading from stdin fails.
+func askMissing(p []string, min int, names []string) []string {
+	for i := len(p); i < min; {
+		name := fmt.Sprintf(Translate("Argument %d"), i+1)
+		if i < len(names) {
+			name = names[i]
+		}
+		fmt.Fprint(Stdout, name+": ")
+		l, err := readLine(Stdin)
+		if l = strings.TrimSpace(l); l != "" {
+			p = append(p, l)
+			i++
+		}
+		if err != nil {
+			if err == io.EOF {
+				fmt.Fprintln(Stdout)
+			}
+			break
+		}
+	}
+	return p
+}
+
+func isOptional(val flagValue) bool {
+	switch v := val.value.(type) {
+	case flagString:
+		return v.o
+	case flagEnum:
+		return v.o
+	case flagInt:
+		return v.o
+	case flagInt32:
+		return v.o
+	case flagInt64:
+		return v.o
+	case flagByteSize:
+		return v.o
+	case flagFloat64:
+		return v.o
+	case flagStringList:
+		return v.o
+	case flagIntList:
+		return v.o
+	case flagVar:
+		return v.o
+	default:
+		return false
+	}
+}
+
+func (f flagEnum) valid(val string) bool {
+	for _, a := range f.allowed {
+		if a == val {
+			return true
+		}
+	}
+	return false
+}
+
 func acceptsValue(val flagValue) bool {
 	switch val.value.(type) {
 	case nil, flagBool, flagIntCounter:
@@ -490,16 +1136,42 @@ func acceptsValue(val flagValue) bool {
 	}
 }
 
+// slashFlag converts "/flag:value" to "-flag=value", or returns "" if it's not a
+// known flag.
+func (f *Flags) slashFlag(arg string) string {
+	name, val := arg[1:], ""
+	if i := strings.IndexAny(name, ":="); i > -1 {
+		name, val = name[:i], name[i+1:]
+	}
+	if _, ok := f.names[name]; !ok {
+		return ""
+	}
+	if len(name)+1 == len(arg) {
+		return "-" + name
+	}
+	return "-" + name + "=" + val
+}
+
+// singleDashLong reports if this is a flag with a long name starting with a
+// single '-', such as "-verbose" or "-out=file".
+func singleDashLong(arg string) bool {
+	if strings.HasPrefix(arg, "--") {
+		return false
+	}
+	name := arg[1:]
+	if i := strings.IndexByte(name, '='); i > -1 {
+		name = name[:i]
+	}
+	return utf8.RuneCountInString(name) > 1
+}
+
 func (f *Flags) match(arg string) (flagValue, bool) {
 	arg = strings.TrimLeft(arg, "-")
-	for _, flag := range f.flags {
-		for _, name := range flag.names {
-			if name == arg || strings.HasPrefix(arg, name+"=") {
-				return flag, true
-			}
-		}
+	if i := strings.IndexByte(arg, '='); i > -1 {
+		arg = arg[:i]
 	}
-	return flagValue{}, false
+	flag, ok := f.names[arg]
+	return flag, ok
 }
 
 type (
@@ -507,54 +1179,84 @@ type (
 		v *bool
 		s *bool
 		o bool // Doesn't make much sense here, but just for consistency.
+		m *flagMeta
 	}
 	flagString struct {
 		v *string
 		s *bool
 		o bool
+		m *flagMeta
+	}
+	flagEnum struct {
+		v       *string
+		s       *bool
+		o       bool
+		m       *flagMeta
+		allowed []string
 	}
 	flagInt struct {
 		v *int
 		s *bool
 		o bool
+		m *flagMeta
 	}
 	flagInt32 struct {
 		v *int32
 		s *bool
 		o bool
+		m *flagMeta
 	}
 	flagInt64 struct {
 		v *int64
 		s *bool
 		o bool
+		m *flagMeta
+	}
+	flagByteSize struct {
+		v *int64
+		s *bool
+		o bool
+		m *flagMeta
 	}
 	flagFloat64 struct {
 		v *float64
 		s *bool
 		o bool
+		m *flagMeta
 	}
 	flagIntCounter struct {
 		v *int
 		s *bool
 		o bool
+		m *flagMeta
 	}
 	flagStringList struct {
 		v *[]string
 		s *bool
 		o bool
+		m *flagMeta
 	}
 	flagIntList struct {
 		v *[]int
 		s *bool
 		o bool
+		m *flagMeta
+	}
+	flagVar struct {
+		v encoding.TextUnmarshaler
+		s *bool
+		o bool
+		m *flagMeta
 	}
 )
 
 func (f flagBool) Pointer() *bool           { return f.v }
 func (f flagString) Pointer() *string       { return f.v }
+func (f flagEnum) Pointer() *string         { return f.v }
 func (f flagInt) Pointer() *int             { return f.v }
 func (f flagInt32) Pointer() *int32         { return f.v }
 func (f flagInt64) Pointer() *int64         { return f.v }
+func (f flagByteSize) Pointer() *int64      { return f.v }
 func (f flagFloat64) Pointer() *float64     { return f.v }
 func (f flagIntCounter) Pointer() *int      { return f.v }
 func (f flagStringList) Pointer() *[]string { return f.v }
@@ -562,9 +1264,11 @@ func (f flagIntList) Pointer() *[]int       { return f.v }
 
 func (f flagBool) Bool() bool              { return *f.v }
 func (f flagString) String() string        { return *f.v }
+func (f flagEnum) String() string          { return *f.v }
 func (f flagInt) Int() int                 { return *f.v }
 func (f flagInt32) Int32() int32           { return *f.v }
 func (f flagInt64) Int64() int64           { return *f.v }
+func (f flagByteSize) Int64() int64        { return *f.v }
 func (f flagFloat64) Float64() float64     { return *f.v }
 func (f flagIntCounter) Int() int          { return *f.v }
 func (f flagStringList) Strings() []string { return *f.v }
@@ -588,136 +1292,483 @@ func (f flagStringList) StringsSplit(sep string) []string {
 	return l
 }
 
+// StringsUnique returns a list of strings with duplicates removed; the first
+// occurrence is kept.
+func (f flagStringList) StringsUnique() []string {
+	var (
+		l    = make([]string, 0, len(*f.v))
+		seen = make(map[string]struct{}, len(*f.v))
+	)
+	for _, s := range *f.v {
+		if _, ok := seen[s]; !ok {
+			seen[s] = struct{}{}
+			l = append(l, s)
+		}
+	}
+	return l
+}
+
+// StringsSorted returns a sorted copy of the list of strings.
+func (f flagStringList) StringsSorted() []string {
+	l := append(make([]string, 0, len(*f.v)), *f.v...)
+	sort.Strings(l)
+	return l
+}
+
+// IntsUnique returns a list of ints with duplicates removed; the first
+// occurrence is kept.
+func (f flagIntList) IntsUnique() []int {
+	var (
+		l    = make([]int, 0, len(*f.v))
+		seen = make(map[int]struct{}, len(*f.v))
+	)
+	for _, n := range *f.v {
+		if _, ok := seen[n]; !ok {
+			seen[n] = struct{}{}
+			l = append(l, n)
+		}
+	}
+	return l
+}
+
+// IntsSorted returns a sorted copy of the list of ints.
+func (f flagIntList) IntsSorted() []int {
+	l := append(make([]int, 0, len(*f.v)), *f.v...)
+	sort.Ints(l)
+	return l
+}
+
+// Secret marks the flag as secret: the value is never included in error
+// messages. Use this for passwords, API tokens, and the like.
+//
+//	token := f.String("", "token").Secret()
+func (f flagBool) Secret() flagBool             { f.m.secret = true; return f }
+func (f flagString) Secret() flagString         { f.m.secret = true; return f }
+func (f flagEnum) Secret() flagEnum             { f.m.secret = true; return f }
+func (f flagInt) Secret() flagInt               { f.m.secret = true; return f }
+func (f flagInt32) Secret() flagInt32           { f.m.secret = true; return f }
+func (f flagInt64) Secret() flagInt64           { f.m.secret = true; return f }
+func (f flagByteSize) Secret() flagByteSize     { f.m.secret = true; return f }
+func (f flagFloat64) Secret() flagFloat64       { f.m.secret = true; return f }
+func (f flagIntCounter) Secret() flagIntCounter { f.m.secret = true; return f }
+func (f flagStringList) Secret() flagStringList { f.m.secret = true; return f }
+func (f flagIntList) Secret() flagIntList       { f.m.secret = true; return f }
+func (f flagVar) Secret() flagVar               { f.m.secret = true; return f }
+
+// HideDefault doesn't show the default value in Usage(); for example because
+// it's computed from the environment. The default is never shown for Secret()
+// flags.
+func (f flagBool) HideDefault() flagBool             { f.m.hideDefault = true; return f }
+func (f flagString) HideDefault() flagString         { f.m.hideDefault = true; return f }
+func (f flagEnum) HideDefault() flagEnum             { f.m.hideDefault = true; return f }
+func (f flagInt) HideDefault() flagInt               { f.m.hideDefault = true; return f }
+func (f flagInt32) HideDefault() flagInt32           { f.m.hideDefault = true; return f }
+func (f flagInt64) HideDefault() flagInt64           { f.m.hideDefault = true; return f }
+func (f flagByteSize) HideDefault() flagByteSize     { f.m.hideDefault = true; return f }
+func (f flagFloat64) HideDefault() flagFloat64       { f.m.hideDefault = true; return f }
+func (f flagIntCounter) HideDefault() flagIntCounter { f.m.hideDefault = true; return f }
+func (f flagStringList) HideDefault() flagStringList { f.m.hideDefault = true; return f }
+func (f flagIntList) HideDefault() flagIntList       { f.m.hideDefault = true; return f }
+func (f flagVar) HideDefault() flagVar               { f.m.hideDefault = true; return f }
+
+func (m *flagMeta) setHelp(desc, ph string) { m.help, m.placeholder = desc, ph }
+
+// Help sets the description and a placeholder for the value (ph) to show in
+// Usage():
+//
+//	f.String("", "o", "output").Help("Write output to FILE.", "FILE")
+//
+// Which is displayed as:
+//
+//	-o, -output FILE    Write output to FILE.
+//
+// The placeholder can be empty, for example for Bool() flags. It's shown as
+// "[FILE]" for Optional() flags.
+func (f flagBool) Help(desc, ph string) flagBool             { f.m.setHelp(desc, ph); return f }
+func (f flagString) Help(desc, ph string) flagString         { f.m.setHelp(desc, ph); return f }
+func (f flagEnum) Help(desc, ph string) flagEnum             { f.m.setHelp(desc, ph); return f }
+func (f flagInt) Help(desc, ph string) flagInt               { f.m.setHelp(desc, ph); return f }
+func (f flagInt32) Help(desc, ph string) flagInt32           { f.m.setHelp(desc, ph); return f }
+func (f flagInt64) Help(desc, ph string) flagInt64           { f.m.setHelp(desc, ph); return f }
+func (f flagByteSize) Help(desc, ph string) flagByteSize     { f.m.setHelp(desc, ph); return f }
+func (f flagFloat64) Help(desc, ph string) flagFloat64       { f.m.setHelp(desc, ph); return f }
+func (f flagIntCounter) Help(desc, ph string) flagIntCounter { f.m.setHelp(desc, ph); return f }
+func (f flagStringList) Help(desc, ph string) flagStringList { f.m.setHelp(desc, ph); return f }
+func (f flagIntList) Help(desc, ph string) flagIntList       { f.m.setHelp(desc, ph); return f }
+func (f flagVar) Help(desc, ph string) flagVar               { f.m.setHelp(desc, ph); return f }
+
+// Name gets the flag name as it was given on the CLI, including the leading
+// dashes; for example "--colour" for f.Bool(false, "color", "colour"). This is
+// the last one if the flag was given more than once, or "" if it wasn't given.
+func (f flagBool) Name() string       { return f.m.name }
+func (f flagString) Name() string     { return f.m.name }
+func (f flagEnum) Name() string       { return f.m.name }
+func (f flagInt) Name() string        { return f.m.name }
+func (f flagInt32) Name() string      { return f.m.name }
+func (f flagInt64) Name() string      { return f.m.name }
+func (f flagByteSize) Name() string   { return f.m.name }
+func (f flagFloat64) Name() string    { return f.m.name }
+func (f flagIntCounter) Name() string { return f.m.name }
+func (f flagStringList) Name() string { return f.m.name }
+func (f flagIntList) Name() string    { return f.m.name }
+func (f flagVar) Name() string        { return f.m.name }
+
 func (f flagBool) Set() bool       { return *f.s }
 func (f flagString) Set() bool     { return *f.s }
+func (f flagEnum) Set() bool       { return *f.s }
 func (f flagInt) Set() bool        { return *f.s }
 func (f flagInt32) Set() bool      { return *f.s }
 func (f flagInt64) Set() bool      { return *f.s }
+func (f flagByteSize) Set() bool   { return *f.s }
 func (f flagFloat64) Set() bool    { return *f.s }
 func (f flagIntCounter) Set() bool { return *f.s }
 func (f flagStringList) Set() bool { return *f.s }
 func (f flagIntList) Set() bool    { return *f.s }
+func (f flagVar) Set() bool        { return *f.s }
 
-func (f *Flags) append(v any, n string, a ...string) {
+func (f *Flags) append(v any, m *flagMeta, n string, a ...string) {
 	for i := range a {
 		a[i] = strings.TrimLeft(a[i], "-")
 	}
+	m.group = f.group
+	m.hidden, f.hidden = f.hidden, false
 	f.flags = append(f.flags, flagValue{
 		value: v,
+		meta:  m,
 		names: append([]string{strings.TrimLeft(n, "-")}, a...),
 	})
 }
 
+// newFlag allocates the value, "is set" flag, metadata, and a copy of the
+// default together.
+func newFlag[T any](def T) (*T, *bool, *flagMeta) {
+	p := &struct {
+		v T
+		d T
+		s bool
+		m flagMeta
+	}{v: def, d: def}
+	p.m.def = &p.d
+	return &p.v, &p.s, &p.m
+}
+
 // Optional indicates the next flag may optionally have value.
 //
 // By default String(), Int(), etc. require a value, but with Optional() set
 // both "-str" and "-str foo" will work. The default value will be used if
 // "-str" was used.
+//
+// Use the OptionalEquals() parse option to only accept "-str=foo".
 func (f *Flags) Optional() *Flags {
 	f.optional = true
 	return f
 }
 
-// TODO: consider adding a method to automatically generate errors on conflicts;
-// for example:
+// Hidden indicates the next flag is hidden: it can be used as any other flag,
+// but isn't listed in Usage(). This is useful for internal or debug flags:
+//
+//	f.Hidden().Bool(false, "debug-dump")
+//
+// It can be combined with Optional(): f.Hidden().Optional().String(...)
+func (f *Flags) Hidden() *Flags {
+	f.hidden = true
+	return f
+}
+
+// Group sets the group for all flags that are added after this, until the next
+// call to Group(). The flags are listed under this group name in Usage():
 //
-//   f.Conflicts(
-//      "-json", "-toml",    // These two conflict
-//      "cmd1", "-json",     // cmd1 doesn't support -json
-//   )
+//	f.Group("Output options")
+//	f.Bool(false, "json")
+//	f.String("", "o", "output")
 //
-// func (f *Flags) Conflicts(args ...string) {
-// }
+// Flags added before the first Group() call, or after Group(""), are listed
+// under "Options".
+func (f *Flags) Group(name string) {
+	f.group = name
+}
+
+// Conflicts adds pairs of flags or commands that can't be used together, which
+// makes Parse() return an ErrFlagConflict if both are used:
+//
+//	f.Conflicts(
+//	    "-json", "-toml",    // These two conflict
+//	    "cmd1", "-json",     // cmd1 doesn't support -json
+//	)
+//
+// Flags must start with a "-"; any alias can be used. Everything else is a
+// command, which is matched against the command returned by ShiftCommand().
+//
+// This panics if the number of arguments isn't even.
+func (f *Flags) Conflicts(pairs ...string) {
+	if len(pairs)%2 != 0 {
+		panic("zli.Flags.Conflicts: uneven number of arguments")
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		f.conflicts = append(f.conflicts, [2]string{pairs[i], pairs[i+1]})
+	}
+}
+
+// Deprecated marks a flag name as deprecated. It's still accepted, but using it
+// prints a warning with Warnf():
+//
+//	f.String("", "new-name", "old-name")
+//	f.Deprecated("old-name", "use -new-name")
+//
+//	% prog -old-name=x
+//	prog: warning: -old-name is deprecated; use -new-name
+//
+// The name can be the name of any flag or alias, and is no longer listed in
+// Usage(). Use DeprecatedUsed() to get a list of deprecated names that were
+// used.
+func (f *Flags) Deprecated(name, msg string) {
+	if f.deprecated == nil {
+		f.deprecated = make(map[string]string)
+	}
+	f.deprecated[strings.TrimLeft(name, "-")] = msg
+}
+
+// DeprecatedUsed gets all deprecated flags used in Parse(), as they were given
+// on the commandline (e.g. "--old-name").
+func (f *Flags) DeprecatedUsed() []string { return f.deprecatedUsed }
+
+// used reports if the flag or command is used, and returns the name to use in
+// errors.
+func (f *Flags) used(name string) (string, bool) {
+	if !strings.HasPrefix(name, "-") {
+		return name, name == f.command
+	}
+	flag, ok := f.match(name)
+	if !ok || !flag.value.(setter).Set() {
+		return name, false
+	}
+	if flag.meta.name != "" {
+		return flag.meta.name, true
+	}
+	return name, true
+}
 
 func (f *Flags) Bool(def bool, name string, aliases ...string) flagBool {
-	v := flagBool{v: &def, s: new(bool), o: f.optional}
+	val, set, m := newFlag(def)
+	v := flagBool{v: val, s: set, o: f.optional, m: m}
 	if f.optional {
 		f.optional = false
 	}
-	f.append(v, name, aliases...)
+	f.append(v, m, name, aliases...)
 	return v
 }
 func (f *Flags) String(def, name string, aliases ...string) flagString {
-	v := flagString{v: &def, s: new(bool), o: f.optional}
+	val, set, m := newFlag(def)
+	v := flagString{v: val, s: set, o: f.optional, m: m}
+	if f.optional {
+		f.optional = false
+	}
+	f.append(v, m, name, aliases...)
+	return v
+}
+
+// Enum adds a string flag which must be one of the allowed values; Parse()
+// returns ErrFlagEnum if it's not:
+//
+//	color := f.Enum("auto", []string{"auto", "always", "never"}, "color")
+//
+// The default value isn't checked, and can be something that's not allowed on
+// the CLI.
+func (f *Flags) Enum(def string, allowed []string, name string, aliases ...string) flagEnum {
+	val, set, m := newFlag(def)
+	v := flagEnum{v: val, s: set, o: f.optional, m: m, allowed: allowed}
 	if f.optional {
 		f.optional = false
 	}
-	f.append(v, name, aliases...)
+	f.append(v, m, name, aliases...)
 	return v
 }
 func (f *Flags) Int(def int, name string, aliases ...string) flagInt {
-	v := flagInt{v: &def, s: new(bool), o: f.optional}
+	val, set, m := newFlag(def)
+	v := flagInt{v: val, s: set, o: f.optional, m: m}
 	if f.optional {
 		f.optional = false
 	}
-	f.append(v, name, aliases...)
+	f.append(v, m, name, aliases...)
 	return v
 }
 func (f *Flags) Int32(def int32, name string, aliases ...string) flagInt32 {
-	v := flagInt32{v: &def, s: new(bool), o: f.optional}
+	val, set, m := newFlag(def)
+	v := flagInt32{v: val, s: set, o: f.optional, m: m}
 	if f.optional {
 		f.optional = false
 	}
-	f.append(v, name, aliases...)
+	f.append(v, m, name, aliases...)
 	return v
 }
 func (f *Flags) Int64(def int64, name string, aliases ...string) flagInt64 {
-	v := flagInt64{v: &def, s: new(bool), o: f.optional}
+	val, set, m := newFlag(def)
+	v := flagInt64{v: val, s: set, o: f.optional, m: m}
 	if f.optional {
 		f.optional = false
 	}
-	f.append(v, name, aliases...)
+	f.append(v, m, name, aliases...)
+	return v
+}
+
+// ByteSize adds a flag for a number of bytes, which accepts SI and IEC units
+// such as "10MB", "512k", or "1.5GiB"; see ParseBytes().
+func (f *Flags) ByteSize(def int64, name string, aliases ...string) flagByteSize {
+	val, set, m := newFlag(def)
+	v := flagByteSize{v: val, s: set, o: f.optional, m: m}
+	if f.optional {
+		f.optional = false
+	}
+	f.append(v, m, name, aliases...)
 	return v
 }
 func (f *Flags) Float64(def float64, name string, aliases ...string) flagFloat64 {
-	v := flagFloat64{v: &def, s: new(bool), o: f.optional}
+	val, set, m := newFlag(def)
+	v := flagFloat64{v: val, s: set, o: f.optional, m: m}
 	if f.optional {
 		f.optional = false
 	}
-	f.append(v, name, aliases...)
+	f.append(v, m, name, aliases...)
 	return v
 }
 func (f *Flags) IntCounter(def int, name string, aliases ...string) flagIntCounter {
-	v := flagIntCounter{v: &def, s: new(bool), o: f.optional}
+	val, set, m := newFlag(def)
+	v := flagIntCounter{v: val, s: set, o: f.optional, m: m}
 	if f.optional {
 		f.optional = false
 	}
-	f.append(v, name, aliases...)
+	f.append(v, m, name, aliases...)
 	return v
 }
 func (f *Flags) StringList(def []string, name string, aliases ...string) flagStringList {
-	v := flagStringList{v: &def, s: new(bool), o: f.optional}
+	val, set, m := newFlag(def)
+	v := flagStringList{v: val, s: set, o: f.optional, m: m}
 	if f.optional {
 		f.optional = false
 	}
-	f.append(v, name, aliases...)
+	f.append(v, m, name, aliases...)
 	return v
 }
 func (f *Flags) IntList(def []int, name string, aliases ...string) flagIntList {
-	v := flagIntList{v: &def, s: new(bool), o: f.optional}
+	val, set, m := newFlag(def)
+	v := flagIntList{v: val, s: set, o: f.optional, m: m}
 	if f.optional {
 		f.optional = false
 	}
-	f.append(v, name, aliases...)
+	f.append(v, m, name, aliases...)
 	return v
 }
 
+// Var adds a flag for a custom type, which is set with UnmarshalText(). Many
+// types in the standard library implement encoding.TextUnmarshaler, such as
+// netip.Addr, big.Int, and slog.Level:
+//
+//	var level slog.Level
+//	f.Var(&level, "log-level")
+//
+// If it also implements encoding.TextMarshaler then that's used to display the
+// default value in Usage().
+func (f *Flags) Var(v encoding.TextUnmarshaler, name string, aliases ...string) flagVar {
+	var def string
+	if tm, ok := v.(encoding.TextMarshaler); ok {
+		if t, err := tm.MarshalText(); err == nil {
+			def = string(t)
+		}
+	}
+	_, set, m := newFlag(def)
+	fv := flagVar{v: v, s: set, o: f.optional, m: m}
+	if f.optional {
+		f.optional = false
+	}
+	f.append(fv, m, name, aliases...)
+	return fv
+}
+
+// Verbose adds the -v and -verbose flags as an IntCounter, with some helpers to
+// print messages depending on how often it was given:
+//
+//	verbose := f.Verbose()
+//	zli.F(f.Parse())
+//
+//	verbose.Printf("printed with -v")
+//	verbose.V(2).Printf("only printed with -vv")
+//
+// Messages are printed to stderr in the same format as Errorf(). Use
+// SetVerbose() to also use it for Infof() and Debugf():
+//
+//	zli.SetVerbose(verbose.Int())
+func (f *Flags) Verbose() flagVerbose {
+	return flagVerbose{f.IntCounter(0, "v", "verbose")}
+}
+
+type (
+	flagVerbose   struct{ flagIntCounter }
+	verboseLogger bool
+)
+
+// Printf prints a message to stderr if the flag was given at least once.
+func (f flagVerbose) Printf(s any, args ...any) { f.V(1).Printf(s, args...) }
+
+// V gets a logger that only prints if the flag was given at least level times.
+func (f flagVerbose) V(level int) verboseLogger { return verboseLogger(f.Int() >= level) }
+
+// Printf prints a message to stderr if the verbosity level was high enough.
+func (l verboseLogger) Printf(s any, args ...any) {
+	if l {
+		fprintMsg(Stderr, errorPrefix(), s, args...)
+	}
+}
+
+// Version adds the -version and -V flags as a Bool, which can be used to print
+// the version with PrintVersion():
+//
+//	version := f.Version()
+//	verbose := f.Verbose()
+//	zli.F(f.Parse())
+//	version.Handle(verbose.Int() > 0)
+//
+// Handle() does nothing if the flag wasn't given.
+func (f *Flags) Version() flagVersion {
+	return flagVersion{f.Bool(false, "version", "V")}
+}
+
+type flagVersion struct{ flagBool }
+
+// Handle prints the version with PrintVersion() and exits if the flag was
+// given.
+func (f flagVersion) Handle(verbose bool) {
+	if f.Bool() {
+		PrintVersion(verbose)
+		Exit(0)
+	}
+}
+
 // Profile enables CPU and memory profiling via the -cpuprofile and -memprofile
 // flags.
 //
+// The profiles are written when the returned function is called, or when the
+// program exits with Exit(), Fatalf(), or on SIGINT or SIGTERM.
+//
 //	f := zli.NewFlags(os.Args)
 //	zli.F(f.Parse())
 //	defer f.Profile()()
 func (f *Flags) Profile() func() {
-	var stop []func()
+	var (
+		stop []func()
+		once sync.Once
+		done = func() {
+			once.Do(func() {
+				for _, f := range stop {
+					f()
+				}
+			})
+		}
+	)
 	go func() { // Make sure it gets written on ^C
 		s := make(chan os.Signal, 1)
 		signal.Notify(s, exitSignals...)
 		<-s
-		for _, f := range stop {
-			f()
-		}
-		os.Exit(0)
+		defaultExit(0)
 	}()
 
 	if f.cpuProf.Set() {
@@ -742,9 +1793,6 @@ func (f *Flags) Profile() func() {
 		}
 		stop = append(stop, f)
 	}
-	return func() {
-		for _, f := range stop {
-			f()
-		}
-	}
+	AtExit(done)
+	return done
 }
@@ -1,7 +1,10 @@
 package zli_test
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"net/netip"
 	"regexp"
 	"strings"
 	"testing"
@@ -252,6 +255,15 @@ func TestFlags(t *testing.T) {
 				float64 1 → 42.666000
 				args  → 0 []
 			`, ""},
+		{"bytesize", []string{"prog", "-s", "1.5GiB", "-m=512k"},
+			func(f *zli.Flags) []any {
+				return []any{f.ByteSize(0, "s"), f.ByteSize(1024, "m", "max-size"), f.ByteSize(1024, "x")}
+			}, `
+				int64 1 → 1610612736
+				int64 2 → 512000
+				int64 3 → 1024
+				args    → 0 []
+			`, ""},
 		{"intcounter", []string{"prog", "-i", "-i", "-i"},
 			func(f *zli.Flags) []any {
 				return []any{f.IntCounter(0, "i")}
@@ -259,6 +271,20 @@ func TestFlags(t *testing.T) {
 				int 1 → 3
 				args  → 0 []
 			`, ""},
+		{"intcounter value", []string{"prog", "-i=3"},
+			func(f *zli.Flags) []any {
+				return []any{f.IntCounter(0, "i")}
+			}, `
+				int 1 → 3
+				args  → 0 []
+			`, ""},
+		{"intcounter value and repeat", []string{"prog", "-i", "-i=3", "-ii"},
+			func(f *zli.Flags) []any {
+				return []any{f.IntCounter(0, "i")}
+			}, `
+				int 1 → 5
+				args  → 0 []
+			`, ""},
 		{"stringlist", []string{"prog", "-s", "a", "-s", "b", "-s", "c"},
 			func(f *zli.Flags) []any {
 				return []any{f.StringList(nil, "s")}
@@ -310,6 +336,13 @@ func TestFlags(t *testing.T) {
 				int64 1 → 42
 				args    → 1 [-i=no]
 		`, `-i=no: invalid syntax (must be a number)`},
+		{"not a bytesize", []string{"prog", "-i=5 apples"},
+			func(f *zli.Flags) []any {
+				return []any{f.ByteSize(42, "i")}
+			}, `
+				int64 1 → 42
+				args    → 1 [-i=5 apples]
+		`, `-i=5 apples: invalid syntax (must be a size)`},
 		{"not a float", []string{"prog", "-i=no"},
 			func(f *zli.Flags) []any {
 				return []any{f.Float64(42, "i")}
@@ -317,6 +350,13 @@ func TestFlags(t *testing.T) {
 				float64 1 → 42.000000
 				args      → 1 [-i=no]
 		`, `-i=no: invalid syntax (must be a number)`},
+		{"not an intcounter", []string{"prog", "-i=no"},
+			func(f *zli.Flags) []any {
+				return []any{f.IntCounter(0, "i")}
+			}, `
+				int 1 → 0
+				args  → 1 [-i=no]
+		`, `-i=no: invalid syntax (must be a number)`},
 
 		// Argument parsing
 		{"-s=arg", []string{"prog", "-s=xx"},
@@ -653,6 +693,83 @@ func TestFlags(t *testing.T) {
 				int 2    → 18
 				args     → 0 []
 			`, ``},
+		{"short multibyte", []string{"prog", "-éüw€8", "X"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.Bool(false, "é"),
+					f.Bool(false, "ü"),
+					f.String("", "w"),
+				}
+			}, `
+				bool 1   → true
+				bool 2   → true
+				string 3 → "€8"
+				args     → 1 [X]
+			`, ``},
+		{"short all types", []string{"prog", "-ofile.txt", "-i32", "-I64", "-f1.5", "-lx", "-ly", "-n1", "-n2"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.String("", "o"),
+					f.Int32(0, "i"),
+					f.Int64(0, "I"),
+					f.Float64(0, "f"),
+					f.StringList(nil, "l"),
+					f.IntList(nil, "n"),
+				}
+			}, `
+				string 1  → "file.txt"
+				int32 2   → 32
+				int64 3   → 64
+				float64 4 → 1.500000
+				list 5    → [x y]
+				list 6    → [1 2]
+				args      → 0 []
+			`, ``},
+		{"short value with dash", []string{"prog", "-vo-x"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.Bool(false, "v"),
+					f.String("", "o"),
+				}
+			}, `
+				bool 1   → true
+				string 2 → "-x"
+				args     → 0 []
+			`, ``},
+		{"short value with =", []string{"prog", "-vo=x=y"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.Bool(false, "v"),
+					f.String("", "o"),
+				}
+			}, `
+				bool 1   → true
+				string 2 → "x=y"
+				args     → 0 []
+			`, ``},
+		// Value takes precedence over other flags.
+		{"short ambiguous", []string{"prog", "-ov"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.Bool(false, "v"),
+					f.String("", "o"),
+				}
+			}, `
+				bool 1   → false
+				string 2 → "v"
+				args     → 0 []
+			`, ``},
+		{"short invalid", []string{"prog", "-vwx"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.Bool(false, "v"),
+					f.Int(0, "w"),
+				}
+			}, `
+				bool 1   → true
+				int 2    → 0
+				args     → 2 [-v -w=x]
+			`, `-w=x: invalid syntax (must be a number)`},
 		// Not when it's a bool
 		{"short without space", []string{"prog", "-w8"},
 			func(f *zli.Flags) []any {
@@ -693,8 +810,7 @@ func TestFlags(t *testing.T) {
 				args     → 0 []
 			`, ``},
 
-		// TODO: maybe this should go bak to 0? Not sure what makes the most
-		// sense here.
+		// A flag without value doesn't reset it.
 		{"multiple flags opt:multiple", []string{"prog", "-w10", "-w"},
 			func(f *zli.Flags) []any {
 				return []any{
@@ -704,12 +820,68 @@ func TestFlags(t *testing.T) {
 				int 1    → 10
 				args     → 0 []
 			`, ``},
+
+		// OptionalEquals
+		{"optional opt:equals", []string{"prog", "-c", "never", "-i=2"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.Optional().String("always", "c", "color"),
+					f.Optional().Int(1, "i"),
+				}
+			}, `
+				string 1 → "always"
+				int 2    → 2
+				args     → 1 [never]
+			`, ``},
+		{"optional opt:equals", []string{"prog", "-color=never", "-i"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.Optional().String("always", "c", "color"),
+					f.Optional().Int(1, "i"),
+				}
+			}, `
+				string 1 → "never"
+				int 2    → 1
+				args     → 0 []
+			`, ``},
+		{"optional opt:equals", []string{"prog", "-bcnever", "x"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.Bool(false, "b"),
+					f.Optional().String("always", "c", "color"),
+				}
+			}, `
+				bool 1   → true
+				string 2 → "never"
+				args     → 1 [x]
+			`, ``},
+		{"not optional opt:equals", []string{"prog", "-s", "val", "-w8"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.String("", "s"),
+					f.Int(0, "w"),
+				}
+			}, `
+				string 1 → "val"
+				int 2    → 8
+				args     → 0 []
+			`, ``},
+		{"optional opt:equals opt:multiple", []string{"prog", "-c=never", "-c", "x"},
+			func(f *zli.Flags) []any {
+				return []any{
+					f.Optional().String("always", "c"),
+				}
+			}, `
+				string 1 → "never"
+				args     → 1 [x]
+			`, ``},
 	}
 
 	type (
 		booler       interface{ Bool() bool }
 		stringer     interface{ String() string }
 		inter        interface{ Int() int }
+		int32er      interface{ Int32() int32 }
 		int64er      interface{ Int64() int64 }
 		floater      interface{ Float64() float64 }
 		stringlister interface{ Strings() []string }
@@ -721,12 +893,15 @@ func TestFlags(t *testing.T) {
 			flag := zli.NewFlags(tt.args)
 			setFlags := tt.flags(&flag)
 
-			var err error
-			if strings.Contains(tt.name, "opt:multiple") { // Hackity hack!
-				err = flag.Parse(zli.AllowMultiple())
-			} else {
-				err = flag.Parse()
+			// Hackity hack! Positional(0, 0) is the default and does nothing.
+			multiple, equals := zli.Positional(0, 0), zli.Positional(0, 0)
+			if strings.Contains(tt.name, "opt:multiple") {
+				multiple = zli.AllowMultiple()
+			}
+			if strings.Contains(tt.name, "opt:equals") {
+				equals = zli.OptionalEquals()
 			}
+			err := flag.Parse(multiple, equals)
 			if !errorContains(err, tt.wantErr) {
 				t.Fatalf("wrong error\nout:  %v\nwant: %v", err, tt.wantErr)
 			}
@@ -740,6 +915,8 @@ func TestFlags(t *testing.T) {
 					out += fmt.Sprintf("string %d → %q\n", i+1, ff.String())
 				case inter:
 					out += fmt.Sprintf("int %d → %d\n", i+1, ff.Int())
+				case int32er:
+					out += fmt.Sprintf("int32 %d → %d\n", i+1, ff.Int32())
 				case int64er:
 					out += fmt.Sprintf("int64 %d → %d\n", i+1, ff.Int64())
 				case floater:
@@ -809,6 +986,88 @@ func TestShiftCommand(t *testing.T) {
 	}
 }
 
+func TestCommandsUsage(t *testing.T) {
+	tests := []struct {
+		cmds []string
+		desc map[string]string
+		want string
+	}{
+		{nil, nil, "Commands:\n"},
+		{[]string{"help", "commit", "ci=commit", "usage=help", "c=commit"},
+			map[string]string{"help": "Show this help.", "commit": "Record changes.", "other": "Not a command."}, `
+Commands:
+    commit, c, ci    Record changes.
+    help, usage      Show this help.
+`},
+		{[]string{"ci=commit", "status", "commit"},
+			map[string]string{"status": "Show status."}, `
+Commands:
+    commit, ci
+    status        Show status.
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			have := zli.CommandsUsage(tt.cmds, tt.desc)
+			want := strings.TrimLeft(tt.want, "\n")
+			if have != want {
+				t.Errorf("\nhave:\n%s\nwant:\n%s", have, want)
+			}
+		})
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"prog", "build"}, ""},
+		{[]string{"prog", "build", "-json"}, ""},
+		{[]string{"prog", "build", "-json", "-toml"}, "-json and -toml can't be used together"},
+		{[]string{"prog", "build", "-t", "--json"}, "--json and -t can't be used together"},
+		{[]string{"prog", "serve", "-toml"}, ""},
+		{[]string{"prog", "serve", "-json"}, "-json can't be used with serve"},
+		{[]string{"prog", "-json", "s"}, "-json can't be used with serve"},
+		{[]string{"prog", "-v", "s"}, "-v can't be used with serve"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			f := zli.NewFlags(tt.args)
+			f.Bool(false, "json")
+			f.Bool(false, "toml", "t")
+			f.Conflicts(
+				"-json", "-toml",
+				"serve", "-json",
+				"-verbose", "serve",
+				"-undefined", "serve",
+			)
+			f.Bool(false, "v", "verbose")
+
+			_, err := f.ShiftCommand("build", "serve")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = f.Parse()
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("uneven", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("no panic")
+			}
+		}()
+		f := zli.NewFlags([]string{"prog"})
+		f.Conflicts("-a", "-b", "-c")
+	})
+}
+
 func TestPositional(t *testing.T) {
 	tests := []struct {
 		args    []string
@@ -839,6 +1098,126 @@ func TestPositional(t *testing.T) {
 	}
 }
 
+type fakeTerm struct{ *bytes.Buffer }
+
+func (fakeTerm) Fd() uintptr { return 0 }
+
+func TestAskMissing(t *testing.T) {
+	tests := []struct {
+		args     []string
+		term     bool
+		input    string
+		names    []string
+		wantArgs string
+		wantOut  string
+		wantErr  string
+	}{
+		{[]string{"a", "b"}, true, "", nil, "[a b]", "", ""},
+		{[]string{"a"}, true, "b\n", nil, "[a b]", "Argument 2: ", ""},
+		{nil, true, "a\n\n  \nb\n", []string{"Source", "Destination"}, "[a b]",
+			"Source: Destination: Destination: Destination: ", ""},
+		{nil, true, "a\n", []string{"Source"}, "[a]",
+			"Source: Argument 2: \n", "exactly 2 positional arguments required, but 1 given"},
+		{[]string{"a"}, false, "b\n", nil, "[a]", "", "exactly 2 positional arguments required, but 1 given"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			_, in, out := zli.Test(t)
+			in.WriteString(tt.input)
+			if tt.term {
+				save := zli.IsTerminal
+				zli.IsTerminal = func(uintptr) bool { return true }
+				defer func() { zli.IsTerminal = save }()
+				zli.Stdin, zli.Stdout = fakeTerm{in}, fakeTerm{out}
+			}
+
+			f := zli.NewFlags(append([]string{"prog"}, tt.args...))
+			err := f.Parse(zli.Positional(2, 2), zli.AskMissing(tt.names...))
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+			if err == nil && fmt.Sprint(f.Args) != tt.wantArgs {
+				t.Errorf("args\nhave: %v\nwant: %s", f.Args, tt.wantArgs)
+			}
+			if out.String() != tt.wantOut {
+				t.Errorf("out\nhave: %q\nwant: %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestAutoHelp(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantExit int
+		wantOut  string
+	}{
+		{[]string{"prog"}, -1, ""},
+		{[]string{"prog", "-v"}, -1, ""},
+		{[]string{"prog", "-h"}, 0, "Usage: prog\n"},
+		{[]string{"prog", "-vh"}, 0, "Usage: prog\n"},
+		{[]string{"prog", "--help", "-unknown"}, 0, "Usage: prog\n"},
+		{[]string{"prog", "-unknown", "--help"}, 0, "Usage: prog\n"},
+		{[]string{"prog", "-unknown", "-vh"}, 0, "Usage: prog\n"},
+		{[]string{"prog", "-unknown", "-version"}, 0, "prog "},
+		{[]string{"prog", "-v", "--", "-h"}, -1, ""},
+		{[]string{"prog", "-help", "-version"}, 0, "Usage: prog\n"},
+		{[]string{"prog", "-version"}, 0, "prog "},
+		{[]string{"prog", "-H"}, -1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			exit, _, out := zli.Test(t, zli.TestProgram("prog"))
+			f := zli.NewFlags(tt.args)
+			f.Bool(false, "v")
+			f.Bool(false, "H", "hidden")
+
+			var err error
+			func() {
+				defer exit.Recover()
+				err = f.Parse(zli.AutoHelp("Usage: prog\n"), zli.AutoVersion())
+			}()
+			if exit.Code() != tt.wantExit {
+				t.Errorf("exit %d; err: %v", exit.Code(), err)
+			}
+			if tt.wantExit == -1 && err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(out.String(), tt.wantOut) {
+				t.Errorf("\nhave: %q\nwant: %q", out.String(), tt.wantOut)
+			}
+			if have := f.Usage(); have != "Options:\n    -v\n    -H, -hidden\n" {
+				t.Errorf("usage: %q", have)
+			}
+		})
+	}
+
+	t.Run("exists", func(t *testing.T) {
+		exit, _, out := zli.Test(t)
+		f := zli.NewFlags([]string{"prog", "-h", "x", "-version"})
+		host := f.String("", "h")
+		err := f.Parse(zli.AutoHelp("usage"))
+		if !errorContains(err, `unknown flag: "-version"`) {
+			t.Fatal(err)
+		}
+		if host.String() != "x" || exit.Code() != -1 || out.String() != "" {
+			t.Errorf("%q %d %q", host.String(), exit.Code(), out.String())
+		}
+
+		f = zli.NewFlags([]string{"prog", "-help"})
+		f.String("", "h")
+		func() {
+			defer exit.Recover()
+			f.Parse(zli.AutoHelp("usage"))
+		}()
+		if exit.Code() != 0 || out.String() != "usage" {
+			t.Errorf("%d %q", exit.Code(), out.String())
+		}
+	})
+}
+
 func TestDoubleParse(t *testing.T) {
 	f := zli.NewFlags([]string{"prog", "-global", "cmd", "-other"})
 
@@ -867,6 +1246,558 @@ func TestDoubleParse(t *testing.T) {
 	}
 }
 
+func TestSecret(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"prog", "-token=hunter2"}, ""},
+		{[]string{"prog", "-token=hunter2", "-token=hunter3"}, `flag given more than once: "-token"`},
+		{[]string{"prog", "-pin=hunter2"}, "-pin: invalid syntax (must be a number)"},
+		{[]string{"prog", "-p", "hunter2"}, "-p: invalid syntax (must be a number)"},
+		{[]string{"prog", "-phunter2"}, "-p: invalid syntax (must be a number)"},
+		{[]string{"prog", "-pin"}, "-pin: needs an argument"},
+		{[]string{"prog", "-v=3"}, ""},
+		{[]string{"prog", "-v=hunter2"}, "-v: invalid syntax (must be a number)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			f := zli.NewFlags(tt.args)
+			token := f.String("", "token").Secret()
+			f.Int(0, "pin", "p").Secret()
+			v := f.IntCounter(0, "v").Secret()
+
+			err := f.Parse()
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), "hunter") {
+				t.Errorf("secret value in error: %s", err)
+			}
+			if tt.wantErr == "" && token.Set() && token.String() != "hunter2" {
+				t.Errorf("token: %q", token.String())
+			}
+			if tt.wantErr == "" && v.Set() && v.Int() != 3 {
+				t.Errorf("v: %d", v.Int())
+			}
+		})
+	}
+}
+
+func TestEnum(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{[]string{"prog"}, "auto", ""},
+		{[]string{"prog", "-color", "never"}, "never", ""},
+		{[]string{"prog", "-c=always"}, "always", ""},
+		{[]string{"prog", "-calways"}, "always", ""},
+		{[]string{"prog", "-color=nope"}, "auto", `-color=nope: invalid value "nope"; must be one of: "auto", "always", "never"`},
+		{[]string{"prog", "-color", "Never"}, "auto", `-color: invalid value "Never"; must be one of: "auto", "always", "never"`},
+		{[]string{"prog", "-color="}, "auto", `-color=: invalid value ""; must be one of:`},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			f := zli.NewFlags(tt.args)
+			color := f.Enum("auto", []string{"auto", "always", "never"}, "color", "c")
+			err := f.Parse()
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+			if color.String() != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", color.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("typed error", func(t *testing.T) {
+		f := zli.NewFlags([]string{"prog", "-key=hunter2"})
+		f.Enum("", []string{"a", "b"}, "key").Secret()
+		err := f.Parse()
+
+		var enumErr zli.ErrFlagEnum
+		if !errors.As(err, &enumErr) {
+			t.Fatalf("wrong error: %#v", err)
+		}
+		if enumErr.Flag != "-key" || enumErr.Value != "" || len(enumErr.Allowed) != 2 {
+			t.Errorf("%#v", enumErr)
+		}
+		if strings.Contains(err.Error(), "hunter") {
+			t.Errorf("secret value in error: %s", err)
+		}
+	})
+}
+
+func TestBoolValues(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{[]string{"prog", "-v"}, `true true`, ""},
+		{[]string{"prog", "-v=false"}, `false true`, ""},
+		{[]string{"prog", "--v=0"}, `false true`, ""},
+		{[]string{"prog", "-v=1"}, `true true`, ""},
+		{[]string{"prog", "-v=F", "-v=TRUE"}, `true true`, ""},
+		{[]string{"prog", "-v=x"}, ``, `-v=x: invalid syntax (must be a boolean)`},
+		{[]string{"prog", "-v="}, ``, `-v=: invalid syntax (must be a boolean)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			f := zli.NewFlags(tt.args)
+			v := f.Bool(true, "v")
+			err := f.Parse(zli.BoolValues(), zli.AllowMultiple())
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			have := fmt.Sprintf("%t %t", v.Bool(), v.Set())
+			if have != tt.want {
+				t.Errorf("\nhave: %s\nwant: %s", have, tt.want)
+			}
+		})
+	}
+
+	t.Run("secret", func(t *testing.T) {
+		f := zli.NewFlags([]string{"prog", "-v=false"})
+		v := f.Bool(true, "v").Secret()
+		err := f.Parse(zli.BoolValues())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Bool() {
+			t.Error("v is true")
+		}
+
+		f = zli.NewFlags([]string{"prog", "-v=hunter2"})
+		f.Bool(true, "v").Secret()
+		err = f.Parse(zli.BoolValues())
+		if !errorContains(err, "-v: invalid syntax (must be a boolean)") {
+			t.Fatalf("wrong error: %v", err)
+		}
+		if strings.Contains(err.Error(), "hunter") {
+			t.Errorf("secret value in error: %s", err)
+		}
+	})
+}
+
+func TestSlashFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{[]string{"prog", "/v", "/out:file.txt", "/in", "in.txt", "/etc/passwd"},
+			`true "file.txt" "in.txt" [/etc/passwd]`, ""},
+		{[]string{"prog", "/out=a:b", "-v", "/"},
+			`true "a:b" "" [/]`, ""},
+		{[]string{"prog", "/out:", "--", "/v"},
+			`false "" "" [/v]`, ""},
+		{[]string{"prog", "/unknown"},
+			`false "" "" [/unknown]`, ""},
+		{[]string{"prog", "/in"},
+			`false "" "" []`, "-in: needs an argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			f := zli.NewFlags(tt.args)
+			var (
+				v   = f.Bool(false, "v")
+				out = f.String("", "out")
+				in  = f.String("", "in")
+			)
+			err := f.Parse(zli.SlashFlags())
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			have := fmt.Sprintf("%t %q %q %v", v.Bool(), out.String(), in.String(), f.Args)
+			if have != tt.want {
+				t.Errorf("\nhave: %s\nwant: %s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictGNU(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{[]string{"prog", "--verbose", "--out=x"}, `true false "x" []`, ""},
+		{[]string{"prog", "-ve", "-o", "x"}, `true true "x" []`, ""},
+		{[]string{"prog", "-vox"}, `true false "x" []`, ""},
+		{[]string{"prog", "-o=x", "a"}, `false false "x" [a]`, ""},
+		{[]string{"prog", "-verbose"}, "", `unknown flag: "-verbose"`},
+		{[]string{"prog", "-out=x"}, `false false "ut=x" []`, ""}, // Same as getopt
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			f := zli.NewFlags(tt.args)
+			var (
+				v   = f.Bool(false, "v", "verbose")
+				e   = f.Bool(false, "e")
+				out = f.String("", "o", "out")
+			)
+			err := f.Parse(zli.StrictGNU())
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			have := fmt.Sprintf("%t %t %q %v", v.Bool(), e.Bool(), out.String(), f.Args)
+			if have != tt.want {
+				t.Errorf("\nhave: %s\nwant: %s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestVar(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{[]string{"prog"}, "127.0.0.1 false", ""},
+		{[]string{"prog", "-addr", "::1"}, "::1 true", ""},
+		{[]string{"prog", "-a=10.0.0.1"}, "10.0.0.1 true", ""},
+		{[]string{"prog", "-a10.0.0.1"}, "10.0.0.1 true", ""},
+		{[]string{"prog", "-a=x"}, "", `-a=x: ParseAddr("x"): unable to parse IP`},
+		{[]string{"prog", "-secret=hunter2"}, "", `-secret: invalid value`},
+		{[]string{"prog", "-a"}, "", `-a: needs an argument`},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var (
+				addr   = netip.MustParseAddr("127.0.0.1")
+				secret netip.Addr
+			)
+			f := zli.NewFlags(tt.args)
+			addrFlag := f.Var(&addr, "addr", "a")
+			f.Var(&secret, "secret").Secret()
+			err := f.Parse()
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if have := fmt.Sprintf("%s %t", addr, addrFlag.Set()); have != tt.want {
+				t.Errorf("\nhave: %s\nwant: %s", have, tt.want)
+			}
+			if u := f.Usage(); !strings.Contains(u, "-addr, -a  (default: 127.0.0.1)") {
+				t.Errorf("usage:\n%s", u)
+			}
+		})
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		args            []string
+		wantC, wantList string
+	}{
+		{[]string{"prog"}, "", ""},
+		{[]string{"prog", "-color"}, "-color", ""},
+		{[]string{"prog", "--colour=x", "-xl", "a"}, "--colour", "-l"},
+		{[]string{"prog", "-c", "-list=a", "--l", "b"}, "-c", "--l"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			f := zli.NewFlags(tt.args)
+			color := f.Optional().String("", "color", "colour", "c")
+			f.Bool(false, "x")
+			list := f.StringList(nil, "list", "l")
+			err := f.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if color.Name() != tt.wantC {
+				t.Errorf("color: %q", color.Name())
+			}
+			if list.Name() != tt.wantList {
+				t.Errorf("list: %q", list.Name())
+			}
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	tests := []struct {
+		args                []string
+		want, used, wantOut string
+	}{
+		{[]string{"prog", "-new", "x"}, "x", "[]", ""},
+		{[]string{"prog", "-old", "x"}, "x", "[-old]",
+			"prog: warning: -old is deprecated; use -new\n"},
+		{[]string{"prog", "--old=x", "-q"}, "x", "[--old -q]",
+			"prog: warning: --old is deprecated; use -new\nprog: warning: -q is deprecated\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			_, _, out := zli.Test(t, zli.TestProgram("prog"), zli.TestColor(false))
+			f := zli.NewFlags(tt.args)
+			s := f.String("", "new", "old")
+			f.Bool(false, "q")
+			f.Deprecated("-old", "use -new")
+			f.Deprecated("q", "")
+
+			err := f.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.String() != tt.want {
+				t.Errorf("value\nhave: %s\nwant: %s", s.String(), tt.want)
+			}
+			if have := fmt.Sprint(f.DeprecatedUsed()); have != tt.used {
+				t.Errorf("used\nhave: %s\nwant: %s", have, tt.used)
+			}
+			if have := out.String(); have != tt.wantOut {
+				t.Errorf("output\nhave: %q\nwant: %q", have, tt.wantOut)
+			}
+			if have, want := f.Usage(), "Options:\n    -new\n"; have != want {
+				t.Errorf("usage\nhave: %q\nwant: %q", have, want)
+			}
+		})
+	}
+
+	t.Run("quiet", func(t *testing.T) {
+		_, _, out := zli.Test(t)
+		zli.SetVerbose(-1)
+		defer zli.SetVerbose(0)
+
+		f := zli.NewFlags([]string{"prog", "-old"})
+		f.Bool(false, "new", "old")
+		f.Deprecated("old", "use -new")
+		err := f.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != "" || len(f.DeprecatedUsed()) != 1 {
+			t.Errorf("%q %v", out.String(), f.DeprecatedUsed())
+		}
+	})
+}
+
+func TestCollectUnknown(t *testing.T) {
+	tests := []struct {
+		args                  []string
+		wantUnknown, wantArgs string
+	}{
+		{[]string{"prog"}, "[]", "[]"},
+		{[]string{"prog", "-v", "a"}, "[]", "[a]"},
+		{[]string{"prog", "-l", "-v", "--color=auto", "a", "-hx", "b", "-x", "-S", "--", "-y"},
+			"[-l --color=auto -hx -S]", "[a b -y]"},
+		{[]string{"prog", "-vl"}, "[-vl]", "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			f := zli.NewFlags(tt.args)
+			f.Bool(false, "v")
+			f.Bool(false, "x")
+
+			var unknown []string
+			err := f.Parse(zli.CollectUnknown(&unknown))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have := fmt.Sprint(unknown); have != tt.wantUnknown {
+				t.Errorf("unknown\nhave: %s\nwant: %s", have, tt.wantUnknown)
+			}
+			if have := fmt.Sprint(f.Args); have != tt.wantArgs {
+				t.Errorf("args\nhave: %s\nwant: %s", have, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestListHelpers(t *testing.T) {
+	f := zli.NewFlags([]string{"prog", "-s", "b", "-s", "a", "-s", "b", "-s", "c", "-s", "a",
+		"-i", "3", "-i", "1", "-i", "3", "-i", "2"})
+	var (
+		s     = f.StringList(nil, "s")
+		i     = f.IntList(nil, "i")
+		empty = f.StringList(nil, "e")
+	)
+	err := f.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		have any
+		want string
+	}{
+		{s.StringsUnique(), "[b a c]"},
+		{s.StringsSorted(), "[a a b b c]"},
+		{s.Strings(), "[b a b c a]"}, // Not modified.
+		{i.IntsUnique(), "[3 1 2]"},
+		{i.IntsSorted(), "[1 2 3 3]"},
+		{i.Ints(), "[3 1 3 2]"},
+		{empty.StringsUnique(), "[]"},
+		{empty.StringsSorted(), "[]"},
+	}
+	for _, tt := range tests {
+		if have := fmt.Sprint(tt.have); have != tt.want {
+			t.Errorf("\nhave: %s\nwant: %s", have, tt.want)
+		}
+	}
+}
+
+func TestParseGlobal(t *testing.T) {
+	tests := []struct {
+		args    []string
+		opts    bool // AllowUnknown
+		want    string
+		wantErr string
+	}{
+		{[]string{"prog", "serve"},
+			false, `false "" "" serve []`, ""},
+		{[]string{"prog", "-v", "-c", "conf", "serve", "-p=80", "x"},
+			false, `true "conf" "80" serve [x]`, ""},
+		{[]string{"prog", "serve", "-v", "-c", "conf", "-p", "80"},
+			false, `true "conf" "80" serve []`, ""},
+		{[]string{"prog", "-vc", "conf", "serve"},
+			false, `true "conf" "" serve []`, ""},
+		{[]string{"prog", "-cconf", "serve"},
+			false, `false "conf" "" serve []`, ""},
+		{[]string{"prog", "-color", "serve"},
+			false, `false "" "" serve []`, ""},
+		{[]string{"prog", "-color=never", "serve"},
+			false, `false "" "" serve []`, ""},
+		{[]string{"prog", "-v", "--", "serve", "-v", "-p=80"},
+			false, `true "" "80" serve []`, ""},
+		{[]string{"prog", "-v", "--", "-serve"},
+			false, `true "" "" -serve []`, ""},
+		{[]string{"prog", "-v", "serve", "--", "-p=80"},
+			false, `true "" "" serve [-p=80]`, ""},
+
+		// -p is only defined for the command.
+		{[]string{"prog", "-p=80", "serve"},
+			false, "", `unknown flag: "-p=80"`},
+		{[]string{"prog", "-x", "-v", "serve", "a"},
+			true, `true "" "" serve [-x a]`, ""},
+		{[]string{"prog", "-p=80", "-v", "serve", "a"},
+			true, `true "" "80" serve [a]`, ""},
+		{[]string{"prog", "-x"},
+			true, `false "" "" -x []`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			f := zli.NewFlags(tt.args)
+			var (
+				verbose = f.Bool(false, "v")
+				config  = f.String("", "c", "config")
+				_       = f.Optional().String("auto", "color")
+			)
+
+			var err error
+			if tt.opts {
+				err = f.ParseGlobal(zli.AllowUnknown())
+			} else {
+				err = f.ParseGlobal()
+			}
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			cmd := f.Shift()
+			port := f.String("", "p", "port")
+			if tt.opts {
+				err = f.Parse(zli.AllowUnknown())
+			} else {
+				err = f.Parse()
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			have := fmt.Sprintf("%t %q %q %s %v", verbose.Bool(), config.String(), port.String(), cmd, f.Args)
+			if have != tt.want {
+				t.Errorf("\nhave: %s\nwant: %s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"prog"}, ""},
+		{[]string{"prog", "-v"}, "zli.test: one\n"},
+		{[]string{"prog", "-verbose", "-v"}, "zli.test: one\nzli.test: two 2\n"},
+		{[]string{"prog", "-vvv"}, "zli.test: one\nzli.test: two 2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			_, _, out := zli.Test(t)
+
+			f := zli.NewFlags(tt.args)
+			verbose := f.Verbose()
+			err := f.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verbose.Printf("one")
+			verbose.V(2).Printf("two %d", 2)
+			verbose.V(4).Printf("four")
+			if out.String() != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	zli.Translate = func(s string) string {
+		return map[string]string{
+			"unknown flag: %q":  "onbekende vlag: %q",
+			"needs an argument": "heeft een argument nodig",
+		}[s]
+	}
+	defer func() { zli.Translate = func(s string) string { return s } }()
+
+	f := zli.NewFlags([]string{"prog", "-x"})
+	err := f.Parse()
+	if !errorContains(err, `onbekende vlag: "-x"`) {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	f = zli.NewFlags([]string{"prog", "-s"})
+	f.String("", "s")
+	err = f.Parse()
+	if !errorContains(err, `-s: heeft een argument nodig`) {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
 // Just to make sure it's not ridiculously slow or anything.
 func BenchmarkFlag(b *testing.B) {
 	b.ReportAllocs()
@@ -891,3 +1822,91 @@ func errorContains(out error, want string) bool {
 	}
 	return strings.Contains(out.Error(), want)
 }
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"prog"}, ""},
+		{[]string{"prog", "-V"}, "zli.test "},
+		{[]string{"prog", "-version"}, "zli.test "},
+		{[]string{"prog", "-version", "-v"}, "\npath "},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			exit, _, out := zli.Test(t)
+
+			f := zli.NewFlags(tt.args)
+			version := f.Version()
+			verbose := f.Verbose()
+			err := f.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			func() {
+				defer exit.Recover()
+				version.Handle(verbose.Int() > 0)
+			}()
+
+			if tt.want == "" {
+				if exit.Code() != -1 || out.String() != "" {
+					t.Errorf("exit=%d; out=%q", exit.Code(), out.String())
+				}
+				return
+			}
+			if exit.Code() != 0 || !strings.Contains(out.String(), tt.want) {
+				t.Errorf("exit=%d; out=%q", exit.Code(), out.String())
+			}
+		})
+	}
+}
+
+func FuzzFlags(f *testing.F) {
+	f.Add("-vv -s=foo -w8 pos -- -x")
+	f.Add("-bw81 X")
+	f.Add("-wf1 -l a -l b")
+	f.Add("-s")
+	f.Add("-o -o=x -")
+
+	f.Fuzz(func(t *testing.T, in string) {
+		flag := zli.NewFlags(append([]string{"prog"}, strings.Split(in, " ")...))
+		flag.Bool(false, "b")
+		flag.IntCounter(0, "v", "verbose")
+		flag.String("", "s", "str")
+		flag.Int(0, "w")
+		flag.Int64(0, "f")
+		flag.Float64(0, "float")
+		flag.Optional().String("", "o")
+		flag.StringList(nil, "l")
+		flag.IntList(nil, "i")
+		_ = flag.Parse(zli.AllowMultiple(), zli.AllowUnknown())
+	})
+}
+
+func BenchmarkFlagMany(b *testing.B) {
+	var (
+		args  = []string{"prog"}
+		names = make([][2]string, 100)
+	)
+	for i := range names {
+		names[i] = [2]string{fmt.Sprintf("flag%d", i), fmt.Sprintf("alias%d", i)}
+		args = append(args, "-"+names[i][0]+"=x", "-abc")
+	}
+
+	b.ReportAllocs()
+	var err error
+	for n := 0; n < b.N; n++ {
+		flag := zli.NewFlags(args)
+		for _, n := range names {
+			flag.String("", n[0], n[1])
+		}
+		flag.Bool(false, "a")
+		flag.Bool(false, "b")
+		flag.Bool(false, "c")
+		err = flag.Parse(zli.AllowMultiple())
+	}
+	_ = err
+}
@@ -0,0 +1,205 @@
+package zli
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HumanBytes formats a number of bytes with IEC units (powers of 1024):
+//
+//	512          512 B
+//	1500         1.5 KiB
+//	1468006      1.4 MiB
+func HumanBytes(n int64) string {
+	const units = "KMGTPE"
+	if n < 1024 && n > -1024 {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+
+	// Compare the rounded value, so that 1048575 is "1.0 MiB" rather than
+	// "1024.0 KiB".
+	f, i := float64(n)/1024, 0
+	for ; math.Abs(math.Round(f*10)/10) >= 1024 && i < len(units)-1; i++ {
+		f /= 1024
+	}
+	return strconv.FormatFloat(f, 'f', 1, 64) + " " + string(units[i]) + "iB"
+}
+
+// ParseBytes parses a number of bytes with an optional SI (powers of 1000) or
+// IEC (powers of 1024) unit. The unit is case-insensitive, and the "B" is
+// optional:
+//
+//	512          512
+//	512k         512000
+//	10 MB        10000000
+//	1.5GiB       1610612736
+//
+// Fractions are allowed as long as the result is a whole number of bytes:
+// "1.5k" is fine, but "1.5" and "0.5B" are an error.
+//
+// The error wraps strconv.ErrSyntax or strconv.ErrRange.
+func ParseBytes(s string) (int64, error) {
+	numErr := func(err error) error { return fmt.Errorf("zli.ParseBytes: %q: %w", s, err) }
+
+	num := strings.TrimSpace(s)
+	i := strings.IndexFunc(num, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	unit := ""
+	if i > -1 {
+		num, unit = num[:i], strings.ToLower(strings.TrimSpace(num[i:]))
+	}
+	if num == "" {
+		return 0, numErr(strconv.ErrSyntax)
+	}
+
+	unit = strings.TrimSuffix(unit, "b")
+	mult := int64(1)
+	if unit != "" {
+		const units = "kmgtpe"
+		j := strings.IndexByte(units, unit[0])
+		if j == -1 || len(unit) > 2 || (len(unit) == 2 && unit[1] != 'i') {
+			return 0, numErr(strconv.ErrSyntax)
+		}
+		base := int64(1000)
+		if len(unit) == 2 {
+			base = 1024
+		}
+		for ; j >= 0; j-- {
+			mult *= base
+		}
+	}
+
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseInt(num, 10, 64)
+		if err != nil {
+			return 0, numErr(errors.Unwrap(err))
+		}
+		if n > math.MaxInt64/mult {
+			return 0, numErr(strconv.ErrRange)
+		}
+		return n * mult, nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, numErr(errors.Unwrap(err))
+	}
+	f *= float64(mult)
+	if f >= math.MaxInt64 {
+		return 0, numErr(strconv.ErrRange)
+	}
+	// Allow for some floating point imprecision: 1.1k is 1100.0000000000002.
+	r := math.Round(f)
+	if math.Abs(f-r) > 1e-6 {
+		return 0, numErr(strconv.ErrSyntax)
+	}
+	return int64(r), nil
+}
+
+// HumanNumber formats a number with a thousands separator:
+//
+//	1234567      1,234,567
+func HumanNumber(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	start := 0
+	if n < 0 {
+		start = 1
+	}
+	if len(s)-start <= 3 {
+		return s
+	}
+
+	b := make([]byte, 0, len(s)+(len(s)-start-1)/3)
+	b = append(b, s[:start]...)
+	for i, c := range s[start:] {
+		if i > 0 && (len(s)-start-i)%3 == 0 {
+			b = append(b, ',')
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
+
+// HumanDuration formats a duration with a precision that makes sense for the
+// size:
+//
+//	1.234567s    1.23s
+//	133.2s       2m13s
+//	3h25m12s     3h25m
+//	76h          3d4h
+func HumanDuration(d time.Duration) string {
+	if d < 0 {
+		// -d overflows for math.MinInt64, so use a uint64.
+		return "-" + humanDuration(uint64(-(d+1))+1)
+	}
+	return humanDuration(uint64(d))
+}
+
+// humanDuration formats d; it's rounded first and then the unit is picked, so
+// that 59m59.6s is "1h0m" rather than "60m0s".
+func humanDuration(d uint64) string {
+	if d < uint64(time.Minute) {
+		if r := roundDuration(time.Duration(d)); r < time.Minute {
+			return r.String()
+		}
+	}
+
+	const (
+		sec    = uint64(time.Second)
+		minute = uint64(time.Minute)
+		hour   = uint64(time.Hour)
+	)
+	if s := (d + sec/2) / sec; s < 60*60 {
+		return fmt.Sprintf("%dm%ds", s/60, s%60)
+	}
+	if m := (d + minute/2) / minute; m < 24*60 {
+		return fmt.Sprintf("%dh%dm", m/60, m%60)
+	}
+	h := (d + hour/2) / hour
+	return fmt.Sprintf("%dd%dh", h/24, h%24)
+}
+
+// HumanSince formats the time relative to now:
+//
+//	just now
+//	5 minutes ago
+//	3 days ago
+//	in 2 hours
+func HumanSince(t time.Time) string {
+	d := time.Since(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var (
+		n    int64
+		unit string
+	)
+	switch {
+	case d < 10*time.Second:
+		return Translate("just now")
+	case d < time.Minute:
+		n, unit = int64(d/time.Second), "second"
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int64(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int64(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int64(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int64(d/(365*24*time.Hour)), "year"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	if future {
+		return fmt.Sprintf(Translate("in %d %s"), n, Translate(unit))
+	}
+	return fmt.Sprintf(Translate("%d %s ago"), n, Translate(unit))
+}
@@ -0,0 +1,159 @@
+package zli_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"zgo.at/zli"
+)
+
+func TestHumanBytes(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1500, "1.5 KiB"},
+		{1468006, "1.4 MiB"},
+		{-1468006, "-1.4 MiB"},
+		{5 << 40, "5.0 TiB"},
+		{1<<63 - 1, "8.0 EiB"},
+		{-1 << 63, "-8.0 EiB"},
+		{1<<20 - 1, "1.0 MiB"},
+		{1<<20 - 52, "1023.9 KiB"},
+		{1<<10*1000 - 1, "1000.0 KiB"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d", tt.in), func(t *testing.T) {
+			have := zli.HumanBytes(tt.in)
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr string
+	}{
+		{"0", 0, ""},
+		{"512", 512, ""},
+		{"512b", 512, ""},
+		{"512k", 512_000, ""},
+		{"512 KiB", 512 << 10, ""},
+		{"10MB", 10_000_000, ""},
+		{"10mib", 10 << 20, ""},
+		{"1.5GiB", 1610612736, ""},
+		{"1.5g", 1_500_000_000, ""},
+		{" 2T ", 2_000_000_000_000, ""},
+		{"1e", 1_000_000_000_000_000_000, ""},
+		{"8EiB", 0, "value out of range"},
+		{"9223372036854775807", 1<<63 - 1, ""},
+		{"9223372036854775808", 0, "value out of range"},
+		{"", 0, "invalid syntax"},
+		{"MB", 0, "invalid syntax"},
+		{"-5", 0, "invalid syntax"},
+		{"5 bytes", 0, "invalid syntax"},
+		{"5KB/s", 0, "invalid syntax"},
+		{"5xb", 0, "invalid syntax"},
+		{"1.2.3", 0, "invalid syntax"},
+		{"1.1k", 1100, ""},
+		{"0.001k", 1, ""},
+		{"1.5", 0, "invalid syntax"},
+		{"0.5B", 0, "invalid syntax"},
+		{"1.0001k", 0, "invalid syntax"},
+		{"x", 0, `zli.ParseBytes: "x": invalid syntax`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			have, err := zli.ParseBytes(tt.in)
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+			if have != tt.want {
+				t.Errorf("\nhave: %d\nwant: %d", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestHumanNumber(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{-999, "-999"},
+		{1000, "1,000"},
+		{-1000, "-1,000"},
+		{123456, "123,456"},
+		{1234567, "1,234,567"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d", tt.in), func(t *testing.T) {
+			have := zli.HumanNumber(tt.in)
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{1234567 * time.Microsecond, "1.23s"},
+		{1337 * time.Millisecond * 100, "2m14s"},
+		{3*time.Hour + 25*time.Minute + 12*time.Second, "3h25m"},
+		{76 * time.Hour, "3d4h"},
+		{-90 * time.Second, "-1m30s"},
+		{59*time.Second + 996*time.Millisecond, "1m0s"},
+		{59*time.Minute + 59*time.Second + 600*time.Millisecond, "1h0m"},
+		{23*time.Hour + 59*time.Minute + 40*time.Second, "1d0h"},
+		{math.MaxInt64, "106752d0h"},
+		{math.MinInt64, "-106752d0h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in.String(), func(t *testing.T) {
+			have := zli.HumanDuration(tt.in)
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestHumanSince(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "just now"},
+		{-30 * time.Second, "30 seconds ago"},
+		{-5 * time.Minute, "5 minutes ago"},
+		{-61 * time.Minute, "1 hour ago"},
+		{-3 * 24 * time.Hour, "3 days ago"},
+		{-400 * 24 * time.Hour, "1 year ago"},
+		{2*time.Hour + time.Minute, "in 2 hours"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in.String(), func(t *testing.T) {
+			have := zli.HumanSince(time.Now().Add(tt.in))
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
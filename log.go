@@ -0,0 +1,130 @@
+package zli
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var verbosity int
+
+// SetVerbose sets the verbosity level for Warnf(), Infof(), and Debugf().
+//
+// Warnings are printed if the level is 0 or higher, informational messages at
+// 1 or higher, and debug messages at 2 or higher. The default is 0. This
+// integrates well with an IntCounter flag:
+//
+//	verbose := f.IntCounter(0, "v", "verbose")
+//	zli.F(f.Parse())
+//	zli.SetVerbose(verbose.Int())
+//
+// Use -1 to suppress warnings as well.
+func SetVerbose(level int) { verbosity = level }
+
+var (
+	// FormatWarn is the formatting to apply to the "warning: " tag.
+	FormatWarn = Yellow
+
+	// FormatInfo is the formatting to apply to the "info: " tag.
+	FormatInfo = Blue
+
+	// FormatDebug is the formatting to apply to the "debug: " tag.
+	FormatDebug = Dim
+)
+
+// Warnf prints a warning to stderr, prepended with ErrorPrefix and "warning: ".
+//
+// It's not printed if the verbosity level is lower than 0.
+func Warnf(s any, args ...any) { logf(0, "warning: ", FormatWarn, s, args...) }
+
+// Infof prints an informational message to stderr, prepended with ErrorPrefix
+// and "info: ".
+//
+// It's only printed if the verbosity level is 1 or higher.
+func Infof(s any, args ...any) { logf(1, "info: ", FormatInfo, s, args...) }
+
+// Debugf prints a debug message to stderr, prepended with ErrorPrefix and
+// "debug: ".
+//
+// It's only printed if the verbosity level is 2 or higher.
+func Debugf(s any, args ...any) { logf(2, "debug: ", FormatDebug, s, args...) }
+
+func logf(level int, tag string, c Color, s any, args ...any) {
+	if verbosity < level {
+		return
+	}
+	fprintMsg(Stderr, errorPrefix()+colorizeStderr(Translate(tag), c), s, args...)
+}
+
+var (
+	timingMu    sync.Mutex
+	timingOrder []string
+	timings     = make(map[string]timing)
+)
+
+type timing struct {
+	total time.Duration
+	n     int
+}
+
+// Timing records how long something took:
+//
+//	defer zli.Timing("indexing")()
+//
+// The time is printed to stderr when the returned function is called if the
+// verbosity set with SetVerbose() is 1 or higher:
+//
+//	prog: indexing: 1.24s
+//
+// The times are also added up per name; use TimingReport() to print them.
+func Timing(name string) func() {
+	start := time.Now()
+	return func() {
+		d := time.Since(start)
+
+		timingMu.Lock()
+		t, ok := timings[name]
+		if !ok {
+			timingOrder = append(timingOrder, name)
+		}
+		t.total += d
+		t.n++
+		timings[name] = t
+		timingMu.Unlock()
+
+		if verbosity >= 1 {
+			fprintMsg(Stderr, errorPrefix(), "%s: %s", name, roundDuration(d))
+		}
+	}
+}
+
+// TimingReport prints the total time and number of calls for every name
+// recorded with Timing() to stderr, in the order they were first recorded:
+//
+//	indexing  1.24s  3×
+//	writing   0.25s  1×
+func TimingReport() {
+	timingMu.Lock()
+	defer timingMu.Unlock()
+
+	w := 0
+	for _, name := range timingOrder {
+		w = max(w, utf8.RuneCountInString(name))
+	}
+	for _, name := range timingOrder {
+		t := timings[name]
+		fmt.Fprintf(Stderr, "%-*s  %s  %d×\n", w, name, roundDuration(t.total), t.n)
+	}
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d
+	}
+}
@@ -0,0 +1,55 @@
+package zli
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestLogf(t *testing.T) {
+	tests := []struct {
+		level int
+		want  string
+	}{
+		{-1, ""},
+		{0, "zli.test: warning: w\n"},
+		{1, "zli.test: warning: w\nzli.test: info: i 1\n"},
+		{2, "zli.test: warning: w\nzli.test: info: i 1\nzli.test: debug: d [x]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			_, _, out := Test(t)
+			defer func(c bool) { WantColorStderr = c }(WantColorStderr)
+			WantColorStderr = false
+			SetVerbose(tt.level)
+			defer SetVerbose(0)
+
+			Warnf("w")
+			Infof("i %d", 1)
+			Debugf(errors.New("d"), "x")
+			if out.String() != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTiming(t *testing.T) {
+	_, _, out := Test(t)
+	SetVerbose(1)
+	defer SetVerbose(0)
+
+	Timing("first")()
+	Timing("second")()
+	Timing("first")()
+	if !regexp.MustCompile(`^(zli.test: (first|second): [0-9.]+[µnm]?s\n){3}$`).MatchString(out.String()) {
+		t.Errorf("wrong output: %q", out.String())
+	}
+
+	out.Reset()
+	TimingReport()
+	if !regexp.MustCompile(`^first   [0-9.]+[µnm]?s  2×\nsecond  [0-9.]+[µnm]?s  1×\n$`).MatchString(out.String()) {
+		t.Errorf("wrong output: %q", out.String())
+	}
+}
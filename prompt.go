@@ -0,0 +1,102 @@
+package zli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ConfirmEach asks the user to confirm an action for every item in a batch
+// operation, remembering if they answered "all" or "quit":
+//
+//	confirm := zli.NewConfirmEach(force.Bool())
+//	for _, f := range files {
+//	    if !confirm.Ask("overwrite %q?", f) {
+//	        if confirm.Quit() {
+//	            break
+//	        }
+//	        continue
+//	    }
+//	    // ...overwrite the file.
+//	}
+//
+// Which prints:
+//
+//	overwrite "foo"? [y]es/[n]o/[a]ll/[q]uit
+type ConfirmEach struct {
+	all, quit bool
+}
+
+// NewConfirmEach creates a new ConfirmEach. If force is true then Ask() will
+// never ask and always return true, which is convenient with a -force flag.
+func NewConfirmEach(force bool) *ConfirmEach {
+	return &ConfirmEach{all: force}
+}
+
+// Ask the question by writing it to Stdout and reading the answer from Stdin,
+// returning true if the action should be done.
+//
+// It doesn't ask and returns true if the user previously answered "all", and
+// returns false if they previously answered "quit". An empty answer is "no",
+// and it will ask again on unknown answers. It's treated as "quit" if Stdin
+// is closed.
+func (c *ConfirmEach) Ask(question string, a ...any) bool {
+	if c.all {
+		return true
+	}
+	if c.quit {
+		return false
+	}
+	if len(a) > 0 {
+		question = fmt.Sprintf(question, a...)
+	}
+
+	for {
+		fmt.Fprintf(Stdout, "%s %s ", question, Translate("[y]es/[n]o/[a]ll/[q]uit"))
+		answer, err := readLine(Stdin)
+		if err != nil && answer == "" {
+			fmt.Fprintln(Stdout)
+			c.quit = true
+			return false
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		case "n", "no", "":
+			return false
+		case "a", "all":
+			c.all = true
+			return true
+		case "q", "quit":
+			c.quit = true
+			return false
+		}
+	}
+}
+
+// Quit reports if the user answered "quit".
+func (c *ConfirmEach) Quit() bool { return c.quit }
+
+// readLine reads one line from r, without the trailing newline.
+//
+// This reads one byte at a time so it never reads more than one line, as r is
+// usually Stdin and the next read may be from something else.
+func readLine(r io.Reader) (string, error) {
+	var (
+		b    strings.Builder
+		char = make([]byte, 1)
+	)
+	for {
+		n, err := r.Read(char)
+		if n > 0 {
+			if char[0] == '\n' {
+				return strings.TrimSuffix(b.String(), "\r"), nil
+			}
+			b.WriteByte(char[0])
+		}
+		if err != nil {
+			return b.String(), err
+		}
+	}
+}
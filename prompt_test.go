@@ -0,0 +1,56 @@
+package zli
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConfirmEach(t *testing.T) {
+	tests := []struct {
+		force bool
+		in    string
+		want  string
+	}{
+		{false, "y\nn\n\ny\n", "[1 4]"},
+		{false, "yes\nwat\nNO\na\n", "[1 3 4]"},
+		{false, "n\nq\n", "[] quit"},
+		{false, "y\n", "[1] quit"},
+		{true, "", "[1 2 3 4]"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			_, in, _ := Test(t)
+			in.WriteString(tt.in)
+
+			var (
+				c    = NewConfirmEach(tt.force)
+				done = []int{}
+			)
+			for _, i := range []int{1, 2, 3, 4} {
+				if c.Ask("overwrite %d?", i) {
+					done = append(done, i)
+				}
+			}
+
+			have := fmt.Sprint(done)
+			if c.Quit() {
+				have += " quit"
+			}
+			if have != tt.want {
+				t.Errorf("\nhave: %s\nwant: %s", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmEachOutput(t *testing.T) {
+	_, in, out := Test(t)
+	in.WriteString("x\ny\n")
+
+	NewConfirmEach(false).Ask("overwrite %q?", "foo")
+	want := `overwrite "foo"? [y]es/[n]o/[a]ll/[q]uit overwrite "foo"? [y]es/[n]o/[a]ll/[q]uit `
+	if out.String() != want {
+		t.Errorf("\nhave: %q\nwant: %q", out.String(), want)
+	}
+}
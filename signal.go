@@ -0,0 +1,68 @@
+package zli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// signalContexts is the number of active SignalContext() calls.
+var signalContexts int32
+
+// SignalContext returns a context that's cancelled when the program receives
+// an interrupt or termination signal (SIGINT, SIGTERM, or SIGHUP on Unix
+// systems), so long-running commands can shut down gracefully:
+//
+//	ctx, cancel := zli.SignalContext()
+//	defer cancel()
+//
+// If a second signal is received while shutting down the program exits
+// immediately with Exit(), running any AtExit() functions. The exit code is
+// 128 + the signal number, as is the convention in shells.
+//
+// The returned function cancels the context and stops listening for signals,
+// also if a first signal was already received.
+func SignalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := make(chan os.Signal, 2)
+	signal.Notify(s, exitSignals...)
+	atomic.AddInt32(&signalContexts, 1)
+
+	var (
+		done = make(chan struct{})
+		once sync.Once
+		stop = func() {
+			once.Do(func() {
+				signal.Stop(s)
+				atomic.AddInt32(&signalContexts, -1)
+				close(done)
+				cancel()
+			})
+		}
+	)
+	go func() {
+		select {
+		case <-s:
+			cancel()
+		case <-done:
+			return
+		}
+
+		var sig os.Signal
+		select {
+		case sig = <-s:
+		case <-done:
+			return
+		}
+		signal.Stop(s)
+		code := 1
+		if n, ok := sig.(syscall.Signal); ok {
+			code = 128 + int(n)
+		}
+		Exit(code)
+	}()
+	return ctx, stop
+}
@@ -13,3 +13,5 @@ func TerminalSizeChange() <-chan struct{} {
 	// TODO: it looks like this may be possible on Windows:
 	// https://stackoverflow.com/questions/10856926/sigwinch-equivalent-on-windows
 }
+
+func watchSize(fn func()) {}
@@ -0,0 +1,139 @@
+//go:build unix
+
+package zli
+
+import (
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSize(t *testing.T) {
+	var (
+		calls int32
+		width int32 = 100
+	)
+	save := TerminalSize
+	TerminalSize = func(uintptr) (int, int, error) {
+		atomic.AddInt32(&calls, 1)
+		return int(atomic.LoadInt32(&width)), 40, nil
+	}
+	defer func() { TerminalSize = save }()
+	Stdout = os.Stdin // Anything with Fd()
+	defer func() { Stdout = os.Stdout }()
+
+	Size() // Start watching for SIGWINCH.
+	sizeCache.Lock()
+	sizeCache.valid = false
+	sizeCache.Unlock()
+	atomic.StoreInt32(&calls, 0)
+
+	for i := 0; i < 3; i++ {
+		if w, h := Size(); w != 100 || h != 40 {
+			t.Fatalf("wrong size: %d, %d", w, h)
+		}
+	}
+	if c := atomic.LoadInt32(&calls); c != 1 {
+		t.Errorf("TerminalSize() called %d times", c)
+	}
+
+	atomic.StoreInt32(&width, 120)
+	syscall.Kill(syscall.Getpid(), syscall.SIGWINCH)
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if w, _ := Size(); w == 120 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("size not refreshed")
+		}
+	}
+}
+
+func TestSignalContext(t *testing.T) {
+	exit := make(chan int, 1)
+	Exit = func(c int) { exit <- c }
+	defer func() { Exit = defaultExit }()
+
+	ctx, cancel := SignalContext()
+	defer cancel()
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled")
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	select {
+	case c := <-exit:
+		if c != 143 {
+			t.Errorf("wrong exit code: %d", c)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't exit")
+	}
+}
+
+func TestSignalContextStop(t *testing.T) {
+	exit := make(chan int, 1)
+	Exit = func(c int) { exit <- c }
+	defer func() { Exit = defaultExit }()
+
+	// Make sure the signal doesn't kill the test once SignalContext stops
+	// listening.
+	catch := make(chan os.Signal, 2)
+	signal.Notify(catch, syscall.SIGTERM)
+	defer signal.Stop(catch)
+
+	ctx, cancel := SignalContext()
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled")
+	}
+	<-catch
+	cancel()
+	cancel()
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	<-catch
+	select {
+	case c := <-exit:
+		t.Fatalf("exited with %d after cancel", c)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSignalContextHideCursor(t *testing.T) {
+	_, _, out := Test(t)
+	exit := make(chan int, 1)
+	Exit = func(c int) { exit <- c }
+
+	show := HideCursor()
+	ctx, cancel := SignalContext()
+	defer cancel()
+
+	// HideCursor() would exit the test binary here if it handled the signal.
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	show()
+	if have := out.String(); have != "\x1b[?25l\x1b[?25h" {
+		t.Errorf("%q", have)
+	}
+	select {
+	case c := <-exit:
+		t.Fatalf("exited with %d", c)
+	default:
+	}
+}
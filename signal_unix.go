@@ -23,3 +23,14 @@ func TerminalSizeChange() <-chan struct{} {
 	}()
 	return ch
 }
+
+// watchSize calls fn every time the terminal window is resized.
+func watchSize(fn func()) {
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	go func() {
+		for range winch {
+			fn()
+		}
+	}()
+}
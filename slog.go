@@ -0,0 +1,133 @@
+//go:build go1.21
+
+package zli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// SlogHandler is a slog.Handler which writes to Stderr, with colors for the
+// level and short times:
+//
+//	15:04:05 INFO  connected addr=localhost:8080
+//	15:04:05 WARN  slow query took=1.5s query="select * from x"
+//
+// Colors are only used if WantColorStderr is set.
+type SlogHandler struct {
+	opts   slog.HandlerOptions
+	attrs  string
+	prefix string
+	mu     *sync.Mutex
+}
+
+var _ slog.Handler = &SlogHandler{}
+
+// NewSlogHandler creates a new SlogHandler.
+//
+// Only the Level from opts is used; opts may be nil, in which case all
+// messages with slog.LevelInfo or higher are logged.
+func NewSlogHandler(opts *slog.HandlerOptions) *SlogHandler {
+	h := &SlogHandler{mu: new(sync.Mutex)}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *SlogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	min := slog.LevelInfo
+	if h.opts.Level != nil {
+		min = h.opts.Level.Level()
+	}
+	return l >= min
+}
+
+// WithAttrs returns a new SlogHandler with the given attributes added.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		appendAttr(&b, h.prefix, a)
+	}
+	h2 := *h
+	h2.attrs += b.String()
+	return &h2
+}
+
+// WithGroup returns a new SlogHandler with the given group name; the keys of
+// all attributes will be prefixed with it.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix += name + "."
+	return &h2
+}
+
+// Handle writes the record to Stderr.
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.Grow(64 + len(r.Message) + len(h.attrs))
+	if !r.Time.IsZero() {
+		b.WriteString(colorizeStderr(r.Time.Format("15:04:05"), Dim))
+		b.WriteByte(' ')
+	}
+
+	var c Color
+	switch {
+	case r.Level >= slog.LevelError:
+		c = Red | Bold
+	case r.Level >= slog.LevelWarn:
+		c = FormatWarn
+	case r.Level >= slog.LevelInfo:
+		c = FormatInfo
+	default:
+		c = FormatDebug
+	}
+	b.WriteString(colorizeStderr(fmt.Sprintf("%-5s", r.Level), c))
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := fmt.Fprint(Stderr, b.String())
+	return err
+}
+
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(b, prefix, ga)
+		}
+		return
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(colorizeStderr(prefix+a.Key+"=", Dim))
+	v := a.Value.String()
+	if v == "" || strings.IndexFunc(v, func(r rune) bool { return unicode.IsSpace(r) || r == '"' || r == '=' }) > -1 {
+		v = strconv.Quote(v)
+	}
+	b.WriteString(v)
+}
@@ -0,0 +1,43 @@
+//go:build go1.21
+
+package zli
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	_, _, out := Test(t)
+	defer func(c bool) { WantColorStderr = c }(WantColorStderr)
+	WantColorStderr = false
+
+	l := slog.New(NewSlogHandler(&slog.HandlerOptions{Level: slog.LevelDebug}))
+	l.Debug("dbg")
+	l.Info("connected", "addr", "localhost:8080", "empty", "")
+	l.With("id", 42).WithGroup("req").Warn("slow", "query", `select "x"`,
+		slog.Group("g", "a", 1))
+	l.Error("oh noes", slog.Group("", "inline", true))
+
+	// Remove time.
+	got := ""
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		got += line[9:] + "\n"
+	}
+	want := `DEBUG dbg
+INFO  connected addr=localhost:8080 empty=""
+WARN  slow id=42 req.query="select \"x\"" req.g.a=1
+ERROR oh noes inline=true
+`
+	if got != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	out.Reset()
+	l = slog.New(NewSlogHandler(nil))
+	l.Debug("dbg")
+	if out.String() != "" {
+		t.Errorf("logged debug: %q", out.String())
+	}
+}
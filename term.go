@@ -2,9 +2,17 @@ package zli
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"syscall"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
 
 	"zgo.at/zli/internal/term"
 )
@@ -15,6 +23,64 @@ var IsTerminal = func(fd uintptr) bool { return term.IsTerminal(int(fd)) }
 // TerminalSize gets the dimensions of the given terminal.
 var TerminalSize = func(fd uintptr) (width, height int, err error) { return term.GetSize(int(fd)) }
 
+var sizeCache struct {
+	sync.Mutex
+	watch sync.Once
+	valid bool
+	w, h  int
+}
+
+// Size gets the dimensions of the terminal for Stdout, or 80x24 if Stdout isn't
+// a terminal.
+//
+// Unlike TerminalSize() the size is cached, and only refreshed after the
+// terminal window is resized (on SIGWINCH). This makes it cheap to call many
+// times, for example when laying out the screen.
+func Size() (width, height int) {
+	sizeCache.watch.Do(func() {
+		watchSize(func() {
+			sizeCache.Lock()
+			sizeCache.valid = false
+			sizeCache.Unlock()
+		})
+	})
+
+	sizeCache.Lock()
+	defer sizeCache.Unlock()
+	if !sizeCache.valid {
+		sizeCache.w, sizeCache.h = 80, 24
+		if f, ok := Stdout.(interface{ Fd() uintptr }); ok {
+			if w, h, err := TerminalSize(f.Fd()); err == nil && w > 0 && h > 0 {
+				sizeCache.w, sizeCache.h = w, h
+			}
+		}
+		sizeCache.valid = true
+	}
+	return sizeCache.w, sizeCache.h
+}
+
+// StdinPiped reports if Stdin is not an interactive terminal, for example
+// because data is piped or redirected to the program.
+//
+// This uses zli.Stdin rather than os.Stdin, and always reports true if it's not
+// a file (e.g. with Test()).
+func StdinPiped() bool { return !isTerm(Stdin) }
+
+// StdoutPiped reports if Stdout is not an interactive terminal, for example
+// because the output is piped to another program or redirected to a file.
+//
+// This uses zli.Stdout rather than os.Stdout, and always reports true if it's
+// not a file (e.g. with Test()).
+func StdoutPiped() bool { return !isTerm(Stdout) }
+
+// Interactive reports if both Stdin and Stdout are interactive terminals.
+func Interactive() bool { return isTerm(Stdin) && isTerm(Stdout) }
+
+func isTerm(v any) bool {
+	f, ok := v.(interface{ Fd() uintptr })
+	return ok && IsTerminal(f.Fd())
+}
+
 // WantColor indicates if the program should output any colors. This is
 // automatically set from from the output terminal and NO_COLOR environment
 // variable.
@@ -23,53 +89,359 @@ var TerminalSize = func(fd uintptr) (width, height int, err error) { return term
 //
 // TODO: maybe expand this a bit with WantMonochrome or some such, so you can
 // still output bold/underline/reverse text for people who don't want colors.
-var WantColor = func() bool {
+var WantColor = wantColor(os.Stdout)
+
+// WantColorStderr is like WantColor, but for stderr. It's used for messages
+// printed by Errorf(), Fatalf(), Warnf(), etc.
+//
+// This is set separately, so messages on stderr are still colored if only
+// stdout is redirected (e.g. "prog | wc -l"). If you override WantColor from a
+// flag then you probably want to set this as well.
+var WantColorStderr = wantColor(os.Stderr)
+
+func wantColor(fp *os.File) bool {
 	_, ok := os.LookupEnv("NO_COLOR")
-	return os.Getenv("TERM") != "dumb" && term.IsTerminal(int(os.Stdout.Fd())) && !ok
-}()
+	return os.Getenv("TERM") != "dumb" && term.IsTerminal(int(fp.Fd())) && !ok
+}
 
 // MakeRaw puts the terminal in "raw mode", returning a function to restore the
 // state.
 //
 // If hideCursor is true the cursor will be hidden, and the returned function
 // will restore that as well.
+//
+// The restore function is also registered with AtExit(), so the terminal is
+// restored if the program exits with Exit() or Fatalf(). It's removed from the
+// AtExit() list again once it's called.
 func MakeRaw(hideCursor bool) func() {
-	st, err := term.MakeRaw(int(os.Stdout.Fd()))
+	return makeRaw(os.Stdout, HideCursor, hideCursor)
+}
+
+// MakeRawFile is like MakeRaw(), but puts the terminal for fp in raw mode
+// rather than the terminal for stdout. The escape codes to hide the cursor are
+// written to fp as well.
+//
+// This can be used with TTY() to use raw mode if stdin or stdout aren't a
+// terminal:
+//
+//	tty, closeTTY, err := zli.TTY()
+//	zli.F(err)
+//	defer closeTTY()
+//	defer zli.MakeRawFile(tty, true)()
+func MakeRawFile(fp *os.File, hideCursor bool) func() {
+	return makeRaw(fp, NewTerm(fp).HideCursor, hideCursor)
+}
+
+func makeRaw(fp *os.File, hide func() func(), hideCursor bool) func() {
+	st, err := term.MakeRaw(int(fp.Fd()))
 	F(err)
 	r := func() {}
 	if hideCursor {
-		r = HideCursor()
+		r = hide()
+	}
+	atomic.AddInt32(&rawMode, 1)
+	var (
+		once   sync.Once
+		remove func()
+	)
+	restore := func() {
+		once.Do(func() {
+			remove()
+			r()
+			term.Restore(int(fp.Fd()), st)
+			atomic.AddInt32(&rawMode, -1)
+			fmt.Fprintln(fp)
+		})
+	}
+	remove = addAtExit(restore)
+	return restore
+}
+
+// rawMode is the number of terminals in raw mode.
+var rawMode int32
+
+// IsRaw reports if a terminal was put in raw mode with MakeRaw() or
+// MakeRawFile() and hasn't been restored yet.
+func IsRaw() bool { return atomic.LoadInt32(&rawMode) > 0 }
+
+// RawWriter wraps w to write "\r\n" instead of "\n" while the terminal is in raw
+// mode, and is a no-op otherwise.
+//
+// A "\n" only moves the cursor down in raw mode, without moving it to the start
+// of the line, so output "stair-steps" across the screen. This is useful for
+// log and debug output while raw mode is active:
+//
+//	log.SetOutput(zli.RawWriter(os.Stderr))
+func RawWriter(w io.Writer) io.Writer { return &rawWriter{w: w} }
+
+type rawWriter struct {
+	w  io.Writer
+	cr bool // Last byte written was a "\r".
+}
+
+func (w *rawWriter) Write(p []byte) (int, error) {
+	if !IsRaw() {
+		if len(p) > 0 {
+			w.cr = p[len(p)-1] == '\r'
+		}
+		return w.w.Write(p)
+	}
+
+	b := make([]byte, 0, len(p)+8)
+	for _, c := range p {
+		if c == '\n' && !w.cr {
+			b = append(b, '\r')
+		}
+		b = append(b, c)
+		w.cr = c == '\r'
+	}
+	if _, err := w.w.Write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// OpenTTY opens the terminal the program is running in: /dev/tty on Unix
+// systems, or CONIN$ on Windows.
+//
+// This works even if stdin and stdout are redirected, as long as the program
+// was started from a terminal.
+func OpenTTY() (*os.File, error) {
+	path := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		path = "CONIN$"
 	}
-	return func() { r(); term.Restore(int(os.Stdout.Fd()), st); fmt.Println() }
+	fp, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("zli.OpenTTY: %w", err)
+	}
+	return fp, nil
+}
+
+// TTY gets os.Stdin if it's a terminal, or opens the terminal with OpenTTY()
+// if it's not (for example because data is piped to the program).
+//
+// The returned function closes the file if it was opened with OpenTTY(), and
+// does nothing if it's os.Stdin.
+func TTY() (*os.File, func(), error) {
+	if IsTerminal(os.Stdin.Fd()) {
+		return os.Stdin, func() {}, nil
+	}
+	fp, err := OpenTTY()
+	if err != nil {
+		return nil, nil, err
+	}
+	return fp, func() { fp.Close() }, nil
+}
+
+// PasswordPolicy sets the requirements for AskPasswordPolicy().
+type PasswordPolicy struct {
+	// Minimum length in characters (codepoints).
+	MinLength int
+
+	// Minimum number of character classes: lowercase letters, uppercase
+	// letters, digits, and anything else.
+	Classes int
+
+	// Passwords that are never accepted; this is case-insensitive.
+	Deny []string
+
+	// Check is an additional check; the error is displayed to the user if it
+	// returns one.
+	Check func(pwd string) error
+
+	// Number of attempts before giving up with ErrPasswordAttempts; 0 means no
+	// limit. Entering a password that doesn't match the policy or doesn't
+	// match the confirmation counts as an attempt.
+	Attempts int
 }
 
+// ErrPasswordAttempts is returned by AskPasswordPolicy() if the user didn't
+// enter a valid password in PasswordPolicy.Attempts attempts.
+var ErrPasswordAttempts = errors.New("zli.AskPassword: too many attempts")
+
+// Validate checks if pwd conforms to the policy.
+func (p PasswordPolicy) Validate(pwd string) error {
+	if utf8.RuneCountInString(pwd) < p.MinLength {
+		return fmt.Errorf(Translate("need at least %d characters"), p.MinLength)
+	}
+	if p.Classes > 0 {
+		var lower, upper, digit, other int
+		for _, c := range pwd {
+			switch {
+			case unicode.IsLower(c):
+				lower = 1
+			case unicode.IsUpper(c):
+				upper = 1
+			case unicode.IsDigit(c):
+				digit = 1
+			default:
+				other = 1
+			}
+		}
+		if lower+upper+digit+other < p.Classes {
+			return fmt.Errorf(Translate("need at least %d of: lowercase letters, uppercase letters, digits, other characters"), p.Classes)
+		}
+	}
+	for _, d := range p.Deny {
+		if strings.EqualFold(pwd, d) {
+			return errors.New(Translate("this password is not allowed"))
+		}
+	}
+	if p.Check != nil {
+		return p.Check(pwd)
+	}
+	return nil
+}
+
+var readPassword = term.ReadPassword
+
 // AskPassword interactively asks the user for a password and confirmation.
 //
 // Just a convenient wrapper for term.ReadPassword() to call it how you want to
-// use it much of the time to ask for a new password.
+// use it much of the time to ask for a new password. The password is read from
+// the terminal with TTY(), so this also works if stdin is redirected.
+//
+// This is the same as AskPasswordPolicy() with only MinLength set.
 func AskPassword(minlen int) (string, error) {
-start:
-	fmt.Fprintf(Stdout, "Enter password for new user (will not echo): ")
-	pwd1, err := term.ReadPassword(int(syscall.Stdin))
+	return AskPasswordPolicy(PasswordPolicy{MinLength: minlen})
+}
+
+// AskPasswordPolicy interactively asks the user for a password and
+// confirmation, and asks again until the password conforms to the policy or
+// the number of attempts is exceeded.
+func AskPasswordPolicy(policy PasswordPolicy) (string, error) {
+	tty, closeTTY, err := TTY()
 	if err != nil {
 		return "", err
 	}
-	if len(pwd1) < minlen {
-		fmt.Fprintf(Stdout, "\nNeed at least %d characters\n", minlen)
-		goto start
-	}
+	defer closeTTY()
 
-	fmt.Fprintf(Stdout, "\nConfirm: ")
-	pwd2, err := term.ReadPassword(int(syscall.Stdin))
-	if err != nil {
-		return "", err
+	for i := 1; ; i++ {
+		if policy.Attempts > 0 && i > policy.Attempts {
+			return "", ErrPasswordAttempts
+		}
+
+		fmt.Fprint(Stdout, Translate("Enter password for new user (will not echo): "))
+		pwd1, err := readPassword(int(tty.Fd()))
+		if err != nil {
+			return "", err
+		}
+		if err := policy.Validate(string(pwd1)); err != nil {
+			fmt.Fprintf(Stdout, "\n%s\n", err)
+			continue
+		}
+
+		fmt.Fprint(Stdout, "\n"+Translate("Confirm: "))
+		pwd2, err := readPassword(int(tty.Fd()))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintln(Stdout, "")
+
+		if !bytes.Equal(pwd1, pwd2) {
+			fmt.Fprintln(Stdout, Translate("Passwords did not match; try again."))
+			continue
+		}
+		return string(pwd1), nil
 	}
-	fmt.Fprintln(Stdout, "")
+}
 
-	if !bytes.Equal(pwd1, pwd2) {
-		fmt.Fprintln(Stdout, "Passwords did not match; try again.")
-		goto start
+// ErrSecretMissing is returned by ReadSecret() if none of the sources have a
+// value.
+type ErrSecretMissing struct {
+	Sources []string
+}
+
+func (e ErrSecretMissing) Error() string {
+	return fmt.Sprintf(Translate("no value in any of: %s"), strings.Join(e.Sources, ", "))
+}
+
+// ReadSecret reads a secret such as a password or API token from the first
+// source that has a value, so it doesn't need to be given on the commandline
+// where it shows up in the shell history and process list:
+//
+//	token, err := zli.ReadSecret("env:API_TOKEN", "file:/run/secrets/token", "prompt:Token: ")
+//
+// The sources are:
+//
+//	env:VAR       Environment variable.
+//	file:PATH     Contents of a file; a file that doesn't exist is treated as
+//	              missing.
+//	fd:N          Everything read from file descriptor N. The file descriptor
+//	              is closed after reading, and 0, 1, and 2 (stdin, stdout,
+//	              stderr) are not allowed.
+//	prompt:TEXT   Ask for the value on the terminal without echo, using TEXT as
+//	              the prompt. This is skipped if there is no terminal.
+//
+// A single trailing newline is removed from file and fd values, and an empty
+// value is treated as missing for all sources. An ErrSecretMissing is returned
+// if none of the sources have a value.
+func ReadSecret(sources ...string) (string, error) {
+	for _, src := range sources {
+		kind, arg, ok := strings.Cut(src, ":")
+		if !ok {
+			return "", fmt.Errorf("zli.ReadSecret: invalid source: %q", src)
+		}
+
+		switch kind {
+		default:
+			return "", fmt.Errorf("zli.ReadSecret: invalid source: %q", src)
+		case "env":
+			if v := os.Getenv(arg); v != "" {
+				return v, nil
+			}
+		case "file":
+			v, err := os.ReadFile(arg)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return "", fmt.Errorf("zli.ReadSecret: %w", err)
+			}
+			if v := trimNewline(string(v)); v != "" {
+				return v, nil
+			}
+		case "fd":
+			n, err := strconv.ParseUint(arg, 10, 0)
+			if err != nil || n <= 2 {
+				return "", fmt.Errorf("zli.ReadSecret: invalid source: %q", src)
+			}
+			fp := os.NewFile(uintptr(n), "fd"+arg)
+			if fp == nil {
+				return "", fmt.Errorf("zli.ReadSecret: invalid source: %q", src)
+			}
+			v, err := io.ReadAll(fp)
+			fp.Close()
+			if err != nil {
+				return "", fmt.Errorf("zli.ReadSecret: %w", err)
+			}
+			if v := trimNewline(string(v)); v != "" {
+				return v, nil
+			}
+		case "prompt":
+			tty, closeTTY, err := TTY()
+			if err != nil {
+				continue
+			}
+			fmt.Fprint(Stdout, arg)
+			v, err := readPassword(int(tty.Fd()))
+			closeTTY()
+			fmt.Fprintln(Stdout, "")
+			if err != nil {
+				return "", fmt.Errorf("zli.ReadSecret: %w", err)
+			}
+			if len(v) > 0 {
+				return string(v), nil
+			}
+		}
 	}
+	return "", ErrSecretMissing{Sources: sources}
+}
 
-	return string(pwd1), nil
+func trimNewline(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return strings.TrimSuffix(s[:len(s)-1], "\r")
+	}
+	return s
 }
@@ -0,0 +1,192 @@
+package zli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPiped(t *testing.T) {
+	Test(t)
+	if !StdinPiped() || !StdoutPiped() || Interactive() {
+		t.Error("buffers are interactive")
+	}
+
+	save := IsTerminal
+	IsTerminal = func(uintptr) bool { return true }
+	defer func() { IsTerminal = save }()
+
+	Stdin = os.Stdin
+	if StdinPiped() || !StdoutPiped() || Interactive() {
+		t.Error("wrong for terminal stdin")
+	}
+	Stdout = os.Stdout
+	if StdinPiped() || StdoutPiped() || !Interactive() {
+		t.Error("wrong for terminal stdin and stdout")
+	}
+}
+
+func TestTTY(t *testing.T) {
+	save := IsTerminal
+	IsTerminal = func(uintptr) bool { return true }
+	defer func() { IsTerminal = save }()
+
+	fp, closeTTY, err := TTY()
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeTTY()
+	if fp != os.Stdin {
+		t.Errorf("not stdin: %v", fp.Name())
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		policy  PasswordPolicy
+		pwd     string
+		wantErr string
+	}{
+		{PasswordPolicy{}, "", ""},
+		{PasswordPolicy{MinLength: 4}, "abc", "need at least 4 characters"},
+		{PasswordPolicy{MinLength: 4}, "abcd", ""},
+		{PasswordPolicy{MinLength: 4}, "äöü", "need at least 4 characters"},
+		{PasswordPolicy{MinLength: 4}, "äöüß", ""},
+		{PasswordPolicy{Classes: 3}, "abcABC", "need at least 3 of"},
+		{PasswordPolicy{Classes: 3}, "abcABC1", ""},
+		{PasswordPolicy{Classes: 4}, "aB1€", ""},
+		{PasswordPolicy{Deny: []string{"password"}}, "PassWord", "not allowed"},
+		{PasswordPolicy{Check: func(p string) error {
+			if p == "x" {
+				return errors.New("no x")
+			}
+			return nil
+		}}, "x", "no x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pwd, func(t *testing.T) {
+			err := tt.policy.Validate(tt.pwd)
+			if !errorContains(err, tt.wantErr) {
+				t.Errorf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAskPasswordPolicy(t *testing.T) {
+	save, saveRead := IsTerminal, readPassword
+	IsTerminal = func(uintptr) bool { return true }
+	defer func() { IsTerminal, readPassword = save, saveRead }()
+
+	tests := []struct {
+		input   []string
+		want    string
+		wantErr error
+	}{
+		{[]string{"abcd", "abcd"}, "abcd", nil},
+		{[]string{"abc", "abcd", "abcd"}, "abcd", nil},
+		{[]string{"abcd", "xxxx", "abcd", "abcd"}, "abcd", nil},
+		{[]string{"a", "b", "c", "abcd", "abcd"}, "", ErrPasswordAttempts},
+		{[]string{"abcd", "xxxx", "abcd", "xxxx", "abcd", "xxxx"}, "", ErrPasswordAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			Test(t)
+			input := tt.input
+			readPassword = func(int) ([]byte, error) {
+				p := input[0]
+				input = input[1:]
+				return []byte(p), nil
+			}
+
+			have, err := AskPasswordPolicy(PasswordPolicy{MinLength: 4, Attempts: 3})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("wrong error: %v", err)
+			}
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawWriter(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		w   = RawWriter(&buf)
+	)
+	fmt.Fprint(w, "a\nb\r\n")
+	atomic.AddInt32(&rawMode, 1)
+	fmt.Fprint(w, "c\nd\r\n\n\r")
+	fmt.Fprint(w, "\ne\n")
+	atomic.AddInt32(&rawMode, -1)
+	fmt.Fprint(w, "f\n")
+
+	want := "a\nb\r\nc\r\nd\r\n\r\n\r\ne\r\nf\n"
+	if have := buf.String(); have != want {
+		t.Errorf("\nhave: %q\nwant: %q", have, want)
+	}
+}
+
+func TestReadSecret(t *testing.T) {
+	save, saveRead := IsTerminal, readPassword
+	defer func() { IsTerminal, readPassword = save, saveRead }()
+	readPassword = func(int) ([]byte, error) { return []byte("prompted"), nil }
+
+	tmp := t.TempDir()
+	os.WriteFile(filepath.Join(tmp, "secret"), []byte("from file\r\n"), 0o600)
+	os.WriteFile(filepath.Join(tmp, "empty"), []byte("\n"), 0o600)
+	t.Setenv("ZLI_TEST_SECRET", "from env")
+	t.Setenv("ZLI_TEST_EMPTY", "")
+
+	tests := []struct {
+		term    bool
+		sources []string
+		want    string
+		wantErr string
+	}{
+		{false, []string{"env:ZLI_TEST_SECRET"}, "from env", ""},
+		{false, []string{"env:ZLI_TEST_EMPTY", "env:ZLI_TEST_SECRET"}, "from env", ""},
+		{false, []string{"file:" + tmp + "/nonexistent", "file:" + tmp + "/secret"}, "from file", ""},
+		{false, []string{"file:" + tmp + "/empty", "env:ZLI_TEST_SECRET"}, "from env", ""},
+		{true, []string{"env:ZLI_TEST_EMPTY", "prompt:Token: "}, "prompted", ""},
+
+		{false, []string{"env:ZLI_TEST_EMPTY", "file:" + tmp + "/nonexistent"}, "",
+			"no value in any of: env:ZLI_TEST_EMPTY, file:" + tmp + "/nonexistent"},
+		{false, nil, "", "no value in any of: "},
+		{false, []string{"ZLI_TEST_SECRET"}, "", `invalid source: "ZLI_TEST_SECRET"`},
+		{false, []string{"fd:x"}, "", `invalid source: "fd:x"`},
+		{false, []string{"fd:0"}, "", `invalid source: "fd:0"`},
+		{false, []string{"fd:2"}, "", `invalid source: "fd:2"`},
+		{false, []string{"file:" + tmp}, "", "is a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			_, _, out := Test(t)
+			IsTerminal = func(uintptr) bool { return tt.term }
+
+			have, err := ReadSecret(tt.sources...)
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %s", err, tt.wantErr)
+			}
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+			if tt.term && out.String() != "Token: \n" {
+				t.Errorf("output: %q", out.String())
+			}
+		})
+	}
+
+	var missing ErrSecretMissing
+	if _, err := ReadSecret("env:ZLI_TEST_EMPTY"); !errors.As(err, &missing) {
+		t.Errorf("wrong error: %#v", err)
+	}
+}
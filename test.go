@@ -2,74 +2,237 @@ package zli
 
 import (
 	"bytes"
+	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
-	"unsafe"
 )
 
 // TestExit records the exit code and aborts the normal program execution. It's
 // intended to test exit codes in a program.
 //
-// The Exit() method call is a replacement for zli.Exit:
+// The Exit() method call is a replacement for zli.Exit. It's easiest to use
+// Test(), which sets this up and restores the original zli.Exit when the test
+// finishes:
 //
-//	exit := TestExit(-1)
-//	Exit = exit.Exit
-//	defer func() { Exit = os.Exit }()
+//	exit, _, _ := zli.Test(t)
+//
+// Or to set it manually:
+//
+//	exit := new(zli.TestExit)
+//	save := zli.Exit
+//	zli.Exit = exit.Exit
+//	defer func() { zli.Exit = save }()
 //
 // This can be recovered like so:
 //
 //	func() {
 //	    defer exit.Recover()
-//	    Fatal("oh noes!")
+//	    zli.Fatalf("oh noes!")
 //	}()
-//	fmt.Println("Exit", exit)
+//	fmt.Println("Exit", exit.Code())
 //
 // The function wrapper is needed so that the test function itself doesn't get
 // aborted.
 //
-// TODO: this isn't thread-safe in cases where os.Exit() gets called from a
-// goroutine in the running program.
+// It's safe to call Exit() from multiple goroutines; every call is recorded.
+// Exit() panics on the goroutine it's called from, so that goroutine needs a
+// deferred Recover() as well, or the panic will crash the test binary. Done()
+// can be used to wait for a goroutine to exit:
 //
-//	type TextExit struct {
-//	  mu    *sync.Mutex
-//	  exits []int
-//	}
-type TestExit int
+//	go func() {
+//	    defer exit.Recover()
+//	    runServer()
+//	}()
+//	<-exit.Done()
+type TestExit struct {
+	mu    sync.Mutex
+	exits []int
+	done  chan struct{}
+	out   *bytes.Buffer // Set by Test().
+}
 
-// Exit sets TestExit to the given status code and panics with itself.
+// Exit records the given status code and panics with itself.
 func (t *TestExit) Exit(c int) {
-	*t = TestExit(c)
+	t.mu.Lock()
+	t.exits = append(t.exits, c)
+	if t.done == nil {
+		t.done = make(chan struct{})
+	}
+	if len(t.exits) == 1 {
+		close(t.done)
+	}
+	t.mu.Unlock()
 	panic(t)
 }
 
+// Code gets the last recorded exit code, or -1 if Exit() was never called.
+func (t *TestExit) Code() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.exits) == 0 {
+		return -1
+	}
+	return t.exits[len(t.exits)-1]
+}
+
+// Exits gets all recorded exit codes, in the order Exit() was called.
+func (t *TestExit) Exits() []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]int(nil), t.exits...)
+}
+
+// Done returns a channel that's closed when Exit() is called for the first
+// time.
+func (t *TestExit) Done() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done == nil {
+		t.done = make(chan struct{})
+	}
+	return t.done
+}
+
+// String gets the last exit code as a string.
+func (t *TestExit) String() string { return strconv.Itoa(t.Code()) }
+
 // Want checks that the recorded exit code matches the given code and issues a
 // t.Error() if it doesn't.
 func (t *TestExit) Want(tt *testing.T, c int) {
 	tt.Helper()
-	if int(*t) != c {
-		tt.Errorf("wrong exit: %d; want: %d", *t, c)
+	if code := t.Code(); code != c {
+		tt.Errorf("wrong exit: %d; want: %d", code, c)
+	}
+}
+
+// WantError checks that the recorded exit code matches the given code and that
+// the output contains msg, and issues a t.Error() if it doesn't:
+//
+//	exit, _, _ := zli.Test(t)
+//	func() {
+//	    defer exit.Recover()
+//	    run("-unknown")
+//	}()
+//	exit.WantError(t, 1, "unknown flag")
+//
+// This only works with a TestExit created by Test(), as it uses its output
+// buffer.
+func (t *TestExit) WantError(tt *testing.T, c int, msg string) {
+	tt.Helper()
+	t.Want(tt, c)
+	if t.out == nil {
+		tt.Error("TestExit.WantError: no output buffer; use zli.Test()")
+		return
+	}
+	if out := t.out.String(); !strings.Contains(out, msg) {
+		tt.Errorf("output doesn't contain %q:\n%s", msg, out)
+	}
+}
+
+// WantOnce checks that Exit() was called exactly once, with the given code,
+// and issues a t.Error() if it wasn't.
+//
+// This catches code that keeps running after a (replaced) Exit() and exits
+// again, which Want() doesn't detect as it only checks the last code.
+func (t *TestExit) WantOnce(tt *testing.T, c int) {
+	tt.Helper()
+	if exits := t.Exits(); len(exits) != 1 || exits[0] != c {
+		tt.Errorf("wrong exits: %v; want exactly one exit with %d", exits, c)
+	}
+}
+
+// WantNone checks that Exit() was never called, and issues a t.Error() if it
+// was.
+func (t *TestExit) WantNone(tt *testing.T) {
+	tt.Helper()
+	if exits := t.Exits(); len(exits) != 0 {
+		tt.Errorf("wrong exits: %v; want no exit", exits)
+	}
+}
+
+// WantExits checks that Exit() was called with exactly the given codes, in
+// order, and issues a t.Error() if it wasn't.
+func (t *TestExit) WantExits(tt *testing.T, c ...int) {
+	tt.Helper()
+	exits := t.Exits()
+	if fmt.Sprint(exits) != fmt.Sprint(c) {
+		tt.Errorf("wrong exits: %v; want: %v", exits, c)
 	}
 }
 
 // Recover any panics where the argument is this TestExit instance. it will
 // re-panic on any other errors (including other TestExit instances).
+//
+// This can be used on any goroutine.
 func (t *TestExit) Recover() {
 	r := recover()
 	if r == nil {
 		return
 	}
 	exit, ok := r.(*TestExit)
-	if !ok || unsafe.Pointer(t) != unsafe.Pointer(exit) {
+	if !ok || exit != t {
 		panic(r)
 	}
 }
 
+// formats are all the Format* variables, so Test() can reset them.
+var (
+	formats = []*Color{&FormatErrorPrefix, &FormatPanic, &FormatWarn,
+		&FormatInfo, &FormatDebug, &FormatHeader, &FormatFlag, &FormatEnv,
+		&FormatExample, &FormatExampleProgram, &FormatDiffHeader,
+		&FormatDiffHunk, &FormatDiffDel, &FormatDiffAdd, &FormatDiffChange,
+		&FormatRule}
+	defaultFormats = func() []Color {
+		f := make([]Color, len(formats))
+		for i := range formats {
+			f[i] = *formats[i]
+		}
+		return f
+	}()
+)
+
+type (
+	testOpts struct {
+		color *bool
+		prog  *string
+	}
+	testOpt func(*testOpts)
+)
+
+// TestColor sets WantColor and WantColorStderr for the duration of the test,
+// and resets all the Format* variables (FormatHeader, FormatFlag, etc.) to
+// their defaults.
+//
+// Without this the output depends on whether the test runner has a terminal.
+var TestColor = func(c bool) testOpt { return func(o *testOpts) { o.color = &c } }
+
+// TestProgram sets the program name returned by Program() for the duration of
+// the test, so that output like "zli.test: oh noes" doesn't depend on how the
+// test was run:
+//
+//	_, _, out := zli.Test(t, zli.TestProgram("prog"))
+//	zli.Errorf("oh noes")
+//	fmt.Println(out.String()) // prog: oh noes
+var TestProgram = func(name string) testOpt { return func(o *testOpts) { o.prog = &name } }
+
 // Test replaces Stdin, Stdout, Stderr, and Exit for testing.
 //
-// The state will be reset when the test finishes.
+// The state will be reset when the test finishes; this includes WantColor,
+// WantColorStderr, the Format* variables, and the program name set with
+// SetProgram(), so they can be modified in tests.
 //
-// The code points to the latest zli.Exit() return code.
-func Test(t *testing.T) (exit *TestExit, in, out *bytes.Buffer) {
+// The exit code of the latest zli.Exit() call is available with exit.Code().
+func Test(t *testing.T, opts ...testOpt) (exit *TestExit, in, out *bytes.Buffer) {
+	var opt testOpts
+	for _, o := range opts {
+		o(&opt)
+	}
+
 	in = new(bytes.Buffer)
 	Stdin = in
 
@@ -77,16 +240,101 @@ func Test(t *testing.T) (exit *TestExit, in, out *bytes.Buffer) {
 	Stdout = out
 	Stderr = out
 
-	exit = new(TestExit)
-	*exit = -1
+	exit = &TestExit{out: out}
 	Exit = exit.Exit
 
+	var (
+		saveColor, saveColorStderr = WantColor, WantColorStderr
+		saveProg, saveFormats      = progname, make([]Color, len(formats))
+	)
+	for i := range formats {
+		saveFormats[i] = *formats[i]
+	}
+	if opt.color != nil {
+		WantColor, WantColorStderr = *opt.color, *opt.color
+		for i := range formats {
+			*formats[i] = defaultFormats[i]
+		}
+	}
+	if opt.prog != nil {
+		progname = *opt.prog
+	}
+
 	t.Cleanup(func() {
-		Exit = os.Exit
+		Exit = defaultExit
 		Stdin = os.Stdin
 		Stdout = os.Stdout
 		Stderr = os.Stderr
+		WantColor, WantColorStderr = saveColor, saveColorStderr
+		progname = saveProg
+		for i := range formats {
+			*formats[i] = saveFormats[i]
+		}
 	})
 
 	return exit, in, out
 }
+
+// GoldenMode sets how Golden() normalizes escape sequences before comparing.
+type GoldenMode uint8
+
+// Golden modes.
+const (
+	GoldenRaw    GoldenMode = iota // Compare as-is.
+	GoldenStrip                    // Remove all escape sequences.
+	GoldenEscape                   // Write the escape character as \x1b, e.g. "\x1b[1;4m".
+)
+
+var reEscape = regexp.MustCompile(`\x1b(?:\[[0-9;:?<=>]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+
+// StripEscapes removes all terminal escape sequences from text.
+//
+// Unlike DeColor() this also removes cursor movement, erase, and other escape
+// sequences.
+func StripEscapes(text string) string { return reEscape.ReplaceAllString(text, "") }
+
+// Golden compares have with the golden file testdata/name.golden, and issues a
+// t.Error() with a diff if it's different.
+//
+// The output is normalized with mode first, so that for example
+//
+//	zli.Golden(t, "usage", out.String(), zli.GoldenEscape)
+//
+// writes "\x1b[1mUsage:\x1b[0m" in the golden file, rather than the raw (and
+// invisible) escape codes.
+//
+// The golden file is written instead of compared if the ZLI_UPDATE_GOLDEN
+// environment variable is set:
+//
+//	ZLI_UPDATE_GOLDEN=1 go test ./...
+func Golden(t testing.TB, name, have string, mode GoldenMode) {
+	t.Helper()
+
+	switch mode {
+	case GoldenStrip:
+		have = StripEscapes(have)
+	case GoldenEscape:
+		have = strings.ReplaceAll(have, "\x1b", `\x1b`)
+	}
+
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv("ZLI_UPDATE_GOLDEN") != "" {
+		err := os.MkdirAll("testdata", 0o777)
+		if err == nil {
+			err = os.WriteFile(path, []byte(have), 0o666)
+		}
+		if err != nil {
+			t.Fatalf("zli.Golden: %s", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("zli.Golden: %s (set ZLI_UPDATE_GOLDEN=1 to create it)", err)
+	}
+	if have != string(want) {
+		t.Errorf("zli.Golden: output differs from %s:\n%s", path,
+			unifiedDiff(diffLines(splitLines(string(want)), splitLines(have)), 3))
+	}
+}
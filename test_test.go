@@ -4,27 +4,51 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
+	"sync"
 	"testing"
 )
 
 func TestTestExit(t *testing.T) {
-	exit := TestExit(-1)
+	exit := new(TestExit)
 	Exit = exit.Exit
 	defer func() { Exit = os.Exit }()
 
 	func() {
 		defer exit.Recover()
 	}()
-	if exit != -1 {
-		t.Errorf("unexpected code: %d", exit)
+	if exit.Code() != -1 {
+		t.Errorf("unexpected code: %d", exit.Code())
 	}
 
 	func() {
 		defer exit.Recover()
 		Fatalf("oh noes!")
 	}()
-	if exit != 1 {
-		t.Errorf("unexpected code: %d", exit)
+	if exit.Code() != 1 {
+		t.Errorf("unexpected code: %d", exit.Code())
+	}
+}
+
+func TestTestExitGoroutine(t *testing.T) {
+	exit, _, _ := Test(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer exit.Recover()
+			Exit(i)
+		}(i)
+	}
+	<-exit.Done()
+	wg.Wait()
+
+	exits := exit.Exits()
+	sort.Ints(exits)
+	if fmt.Sprint(exits) != "[0 1 2 3 4 5 6 7 8 9]" {
+		t.Error(exits)
 	}
 }
 
@@ -55,10 +79,132 @@ func TestTest(t *testing.T) {
 		defer exit.Recover()
 		et()
 	}()
-	if *exit != 1 {
+	if exit.Code() != 1 {
 		t.Error("wrong exit")
 	}
 	if out.String() != "ET START\n" {
 		t.Errorf("wrong stderr: %q", out.String())
 	}
 }
+
+func TestGolden(t *testing.T) {
+	_, _, out := Test(t, TestColor(true))
+
+	Errorf(Colorize("error", Bold|Red))
+	Golden(t, "golden", out.String(), GoldenEscape)
+}
+
+func TestStripEscapes(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"\x1b[1;4mbold\x1b[0m", "bold"},
+		{"\x1b[38;5;99mX\x1b[0m", "X"},
+		{"\x1b[K\rline", "\rline"},
+		{"\x1b[0;0H\x1b[Jscreen", "screen"},
+		{"\x1b[?25lhidden\x1b[?25h", "hidden"},
+		{"\x1b[4:3mcurl\x1b[0m", "curl"},
+		{"\x1b]0;title\x07text", "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			have := StripEscapes(tt.in)
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+}
+
+func TestTestExitWant(t *testing.T) {
+	exit, _, _ := Test(t)
+	exit.WantNone(t)
+	exit.WantExits(t)
+
+	func() {
+		defer exit.Recover()
+		Exit(2)
+	}()
+	exit.WantOnce(t, 2)
+	exit.WantExits(t, 2)
+
+	func() {
+		defer exit.Recover()
+		Exit(0)
+	}()
+	exit.Want(t, 0)
+	exit.WantExits(t, 2, 0)
+
+	tt := new(testing.T)
+	exit.WantOnce(tt, 0)
+	if !tt.Failed() {
+		t.Error("WantOnce didn't fail")
+	}
+	tt = new(testing.T)
+	exit.WantNone(tt)
+	if !tt.Failed() {
+		t.Error("WantNone didn't fail")
+	}
+}
+
+func TestTestColor(t *testing.T) {
+	save := WantColor
+	t.Run("", func(t *testing.T) {
+		_, _, out := Test(t, TestColor(true))
+		if !WantColor {
+			t.Error("WantColor not set")
+		}
+		FormatHeader = Red
+		fmt.Fprint(Stdout, Usage(UsageHeaders, "Usage:"))
+		if out.String() != "\x1b[31mUsage:\x1b[0m" {
+			t.Errorf("%q", out.String())
+		}
+	})
+	t.Run("", func(t *testing.T) {
+		_, _, out := Test(t, TestColor(false))
+		fmt.Fprint(Stdout, Usage(UsageHeaders, "Usage:"))
+		if out.String() != "Usage:" {
+			t.Errorf("%q", out.String())
+		}
+	})
+	if WantColor != save || FormatHeader != Bold {
+		t.Errorf("not restored: %v %v", WantColor, FormatHeader)
+	}
+}
+
+func TestTestExitWantError(t *testing.T) {
+	exit, _, _ := Test(t)
+
+	func() {
+		defer exit.Recover()
+		FatalCode(ExitUsage, "oh noes: %d", 42)
+	}()
+	exit.WantError(t, ExitUsage, "oh noes: 42")
+
+	tt := new(testing.T)
+	exit.WantError(tt, ExitUsage, "other")
+	if !tt.Failed() {
+		t.Error("WantError didn't fail on wrong message")
+	}
+	tt = new(testing.T)
+	exit.WantError(tt, 1, "oh noes")
+	if !tt.Failed() {
+		t.Error("WantError didn't fail on wrong code")
+	}
+}
+
+func TestTestProgram(t *testing.T) {
+	t.Run("", func(t *testing.T) {
+		_, _, out := Test(t, TestProgram("prog"))
+		Errorf("oh noes!")
+		if out.String() != "prog: oh noes!\n" {
+			t.Errorf("%q", out.String())
+		}
+	})
+	if p := Program(); p != "zli.test" {
+		t.Errorf("not restored: %q", p)
+	}
+}
@@ -0,0 +1,327 @@
+package zli
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestScreen is a virtual terminal screen, for testing full-screen output.
+//
+// It implements io.Writer, and interprets the text and escape sequences written
+// to it as a terminal would: cursor movement, erasing, inserting and deleting
+// lines and characters, and SGR attributes (colors). Any other escape sequences
+// are ignored.
+//
+// A "\n" moves the cursor to the start of the next line, as on a terminal
+// that's not in raw mode. Text that's written past the right edge is wrapped
+// to the next line, and the screen is scrolled if the cursor moves past the
+// bottom.
+//
+//	s := zli.NewTestScreen(80, 24)
+//	zli.Stdout = s
+//	zli.To(2, 4, "Hello")
+//	s.Want(t, 2, 4, "Hello")
+//
+// Like To(), all positions are 1-based.
+type TestScreen struct {
+	width, height int
+	row, col      int // 0-based.
+	attr          string
+	hidden        bool
+	cells         [][]testCell
+	pending       []byte
+}
+
+type testCell struct {
+	r    rune
+	attr string
+}
+
+// NewTestScreen creates a new screen with the given dimensions.
+func NewTestScreen(width, height int) *TestScreen {
+	s := &TestScreen{width: max(width, 1), height: max(height, 1)}
+	s.cells = make([][]testCell, s.height)
+	for i := range s.cells {
+		s.cells[i] = s.blankLine()
+	}
+	return s
+}
+
+func (s *TestScreen) blankLine() []testCell {
+	l := make([]testCell, s.width)
+	for i := range l {
+		l[i] = testCell{r: ' '}
+	}
+	return l
+}
+
+// Write interprets the text and escape sequences in p.
+//
+// Escape sequences or UTF-8 characters that are split over several writes are
+// buffered until they're complete.
+func (s *TestScreen) Write(p []byte) (int, error) {
+	b := append(s.pending, p...)
+	s.pending = nil
+	for len(b) > 0 {
+		switch b[0] {
+		case '\x1b':
+			n := s.escape(b)
+			if n == 0 {
+				s.pending = append([]byte(nil), b...)
+				return len(p), nil
+			}
+			b = b[n:]
+			continue
+		case '\n':
+			s.col = 0
+			s.lineFeed()
+		case '\r':
+			s.col = 0
+		case '\b':
+			s.col = max(s.col-1, 0)
+		case '\t':
+			s.col = min((s.col/8+1)*8, s.width-1)
+		case '\a':
+		default:
+			if !utf8.FullRune(b) {
+				s.pending = append([]byte(nil), b...)
+				return len(p), nil
+			}
+			r, n := utf8.DecodeRune(b)
+			s.put(r)
+			b = b[n:]
+			continue
+		}
+		b = b[1:]
+	}
+	return len(p), nil
+}
+
+func (s *TestScreen) put(r rune) {
+	if s.col >= s.width {
+		s.col = 0
+		s.lineFeed()
+	}
+	s.cells[s.row][s.col] = testCell{r: r, attr: s.attr}
+	s.col++
+}
+
+func (s *TestScreen) lineFeed() {
+	if s.row < s.height-1 {
+		s.row++
+		return
+	}
+	copy(s.cells, s.cells[1:])
+	s.cells[s.height-1] = s.blankLine()
+}
+
+// escape interprets the escape sequence at the start of b, returning the number
+// of bytes it used or 0 if the sequence is incomplete.
+func (s *TestScreen) escape(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	switch b[1] {
+	case '[':
+	case ']': // OSC; ignored.
+		for i := 2; i < len(b); i++ {
+			if b[i] == '\a' {
+				return i + 1
+			}
+			if b[i] == '\x1b' && i+1 < len(b) && b[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return 0
+	default:
+		return 2
+	}
+
+	end := -1
+	for i := 2; i < len(b); i++ {
+		if b[i] >= '@' && b[i] <= '~' {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return 0
+	}
+
+	params := string(b[2:end])
+	if strings.HasPrefix(params, "?") {
+		if params == "?25" {
+			s.hidden = b[end] == 'l'
+		}
+		return end + 1
+	}
+
+	var args []int
+	for _, a := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(a)
+		args = append(args, n)
+	}
+	arg := func(i, def int) int {
+		if i >= len(args) || args[i] == 0 {
+			return def
+		}
+		return args[i]
+	}
+
+	switch b[end] {
+	case 'A':
+		s.row = max(s.row-arg(0, 1), 0)
+	case 'B':
+		s.row = min(s.row+arg(0, 1), s.height-1)
+	case 'C':
+		s.col = min(s.col+arg(0, 1), s.width-1)
+	case 'D':
+		s.col = max(min(s.col, s.width-1)-arg(0, 1), 0)
+	case 'E':
+		s.row, s.col = min(s.row+arg(0, 1), s.height-1), 0
+	case 'F':
+		s.row, s.col = max(s.row-arg(0, 1), 0), 0
+	case 'G':
+		s.col = min(arg(0, 1), s.width) - 1
+	case 'H', 'f':
+		s.row, s.col = min(arg(0, 1), s.height)-1, min(arg(1, 1), s.width)-1
+	case 'K':
+		s.eraseLine(arg(0, 0))
+	case 'J':
+		s.eraseScreen(arg(0, 0))
+	case 'L':
+		n := min(arg(0, 1), s.height-s.row)
+		copy(s.cells[s.row+n:], s.cells[s.row:s.height-n])
+		for i := s.row; i < s.row+n; i++ {
+			s.cells[i] = s.blankLine()
+		}
+	case 'M':
+		n := min(arg(0, 1), s.height-s.row)
+		copy(s.cells[s.row:], s.cells[s.row+n:])
+		for i := s.height - n; i < s.height; i++ {
+			s.cells[i] = s.blankLine()
+		}
+	case '@':
+		l, c := s.cells[s.row], min(s.col, s.width-1)
+		n := min(arg(0, 1), s.width-c)
+		copy(l[c+n:], l[c:s.width-n])
+		for i := c; i < c+n; i++ {
+			l[i] = testCell{r: ' '}
+		}
+	case 'P':
+		l, c := s.cells[s.row], min(s.col, s.width-1)
+		n := min(arg(0, 1), s.width-c)
+		copy(l[c:], l[c+n:])
+		for i := s.width - n; i < s.width; i++ {
+			l[i] = testCell{r: ' '}
+		}
+	case 'm':
+		if params == "" || params == "0" {
+			s.attr = ""
+		} else if strings.HasPrefix(params, "0;") {
+			s.attr = params[2:]
+		} else if s.attr == "" {
+			s.attr = params
+		} else {
+			s.attr += ";" + params
+		}
+	}
+	return end + 1
+}
+
+func (s *TestScreen) eraseLine(mode int) {
+	l, c := s.cells[s.row], min(s.col, s.width-1)
+	from, to := c, s.width
+	switch mode {
+	case 1:
+		from, to = 0, c+1
+	case 2:
+		from = 0
+	}
+	for i := from; i < to; i++ {
+		l[i] = testCell{r: ' '}
+	}
+}
+
+func (s *TestScreen) eraseScreen(mode int) {
+	s.eraseLine(mode)
+	from, to := s.row+1, s.height
+	switch mode {
+	case 1:
+		from, to = 0, s.row
+	case 2, 3:
+		from = 0
+	}
+	for i := from; i < to; i++ {
+		s.cells[i] = s.blankLine()
+	}
+}
+
+// Size gets the screen dimensions.
+func (s *TestScreen) Size() (width, height int) { return s.width, s.height }
+
+// Cursor gets the current cursor position.
+func (s *TestScreen) Cursor() (row, col int) { return s.row + 1, min(s.col, s.width-1) + 1 }
+
+// CursorHidden reports if the cursor is hidden.
+func (s *TestScreen) CursorHidden() bool { return s.hidden }
+
+// Line gets the text on the given row, with trailing spaces removed. It returns
+// "" if the row is outside the screen.
+func (s *TestScreen) Line(row int) string {
+	if row < 1 || row > s.height {
+		return ""
+	}
+	var b strings.Builder
+	for _, c := range s.cells[row-1] {
+		b.WriteRune(c.r)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Attr gets the SGR parameters for the cell at the given position, for example
+// "1;31" for bold red text; this is the same as Color.String() without the
+// "\x1b[" and "m". It returns "" for cells without any attributes.
+func (s *TestScreen) Attr(row, col int) string {
+	if row < 1 || row > s.height || col < 1 || col > s.width {
+		return ""
+	}
+	return s.cells[row-1][col-1].attr
+}
+
+// String gets all the text on the screen, with trailing spaces and empty lines
+// removed.
+func (s *TestScreen) String() string {
+	lines := make([]string, s.height)
+	for i := range lines {
+		lines[i] = s.Line(i + 1)
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// Contains reports if text is on the screen at the given position.
+func (s *TestScreen) Contains(row, col int, text string) bool {
+	if row < 1 || row > s.height || col < 1 {
+		return false
+	}
+	l := []rune(s.Line(row))
+	for len(l) < s.width {
+		l = append(l, ' ')
+	}
+	if col-1+utf8.RuneCountInString(text) > len(l) {
+		return false
+	}
+	return strings.HasPrefix(string(l[col-1:]), text)
+}
+
+// Want checks that text is on the screen at the given position and issues a
+// t.Error() if it isn't.
+func (s *TestScreen) Want(t *testing.T, row, col int, text string) {
+	t.Helper()
+	if !s.Contains(row, col, text) {
+		t.Errorf("screen doesn't contain %q at %d, %d; line %d is:\n%q\nscreen:\n%s",
+			text, row, col, row, s.Line(row), s)
+	}
+}
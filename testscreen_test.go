@@ -0,0 +1,88 @@
+package zli
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestTestScreen(t *testing.T) {
+	s := NewTestScreen(20, 5)
+	Stdout = s
+	defer func() { Stdout = os.Stdout }()
+
+	EraseScreen()
+	fmt.Fprint(Stdout, "header")
+	To(3, 5, "Hello")
+	Move(1, -5, "world")
+	To(2, 1, "%s", Colorize("red", Red))
+
+	s.Want(t, 1, 1, "header")
+	s.Want(t, 3, 5, "Hello")
+	s.Want(t, 4, 5, "world")
+	if have := s.String(); have != "header\nred\n    Hello\n    world" {
+		t.Errorf("\n%s", have)
+	}
+	if row, col := s.Cursor(); row != 2 || col != 4 {
+		t.Errorf("cursor at %d, %d", row, col)
+	}
+
+	To(3, 1, "")
+	Modify(0, 2, "")
+	s.Want(t, 3, 7, "Hello")
+	Modify(0, -6, "")
+	s.Want(t, 3, 1, "Hello")
+	Modify(1, 0, "new")
+	s.Want(t, 3, 1, "new")
+	s.Want(t, 4, 1, "Hello")
+	s.Want(t, 5, 5, "world")
+
+	To(1, 3, "")
+	Erase()
+	s.Want(t, 1, 1, "he")
+	if s.Line(1) != "he" {
+		t.Errorf("%q", s.Line(1))
+	}
+
+	show := HideCursor()
+	if !s.CursorHidden() {
+		t.Error("cursor not hidden")
+	}
+	show()
+	if s.CursorHidden() {
+		t.Error("cursor hidden")
+	}
+
+	EraseScreen()
+	if have := s.String(); have != "" {
+		t.Errorf("\n%s", have)
+	}
+}
+
+func TestTestScreenScroll(t *testing.T) {
+	s := NewTestScreen(5, 3)
+	fmt.Fprint(s, "1\n2\n3\n4\n123456")
+	if have := s.String(); have != "4\n12345\n6" {
+		t.Errorf("\n%s", have)
+	}
+}
+
+func TestTestScreenAttr(t *testing.T) {
+	defer func(c bool) { WantColor = c }(WantColor)
+	WantColor = true
+
+	s := NewTestScreen(10, 2)
+	fmt.Fprint(s, Colorize("x", Bold|Red), "y")
+	if a := s.Attr(1, 1); a != "1;31" {
+		t.Errorf("%q", a)
+	}
+	if a := s.Attr(1, 2); a != "" {
+		t.Errorf("%q", a)
+	}
+
+	// Split over several writes.
+	fmt.Fprint(s, "\x1b[")
+	fmt.Fprint(s, "2;1H\xe2\x82")
+	fmt.Fprint(s, "\xac")
+	s.Want(t, 2, 1, "€")
+}
@@ -0,0 +1,137 @@
+package zli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UpdateCheckInterval is how long CheckUpdate caches the latest version.
+var UpdateCheckInterval = 24 * time.Hour
+
+// CheckUpdate checks if there is a newer version than current.
+//
+// The endpoint is either a GitHub repository URL such as
+// "https://github.com/arp242/uni", in which case the tag of the latest release
+// is used, or any other URL that returns the version as plain text (or JSON
+// with a "tag_name" key).
+//
+// It returns a message suggesting to update if there is a newer version, or an
+// empty string if current is up to date. It also returns an empty string if
+// current is empty or "dev", as that's assumed to be a development version.
+//
+// The latest version is cached for UpdateCheckInterval in the user's cache
+// directory (e.g. ~/.cache/[prog]/update-check), so it's fine to call this on
+// every invocation.
+//
+// This never runs automatically; programs should only call it if the user
+// asked for it, for example with a flag or setting.
+func CheckUpdate(current, endpoint string) (string, error) {
+	if current == "" || current == "dev" {
+		return "", nil
+	}
+
+	cache := updateCachePath()
+	latest, ok := readUpdateCache(cache, endpoint)
+	if !ok {
+		var err error
+		latest, err = fetchLatest(endpoint)
+		if err != nil {
+			return "", fmt.Errorf("zli.CheckUpdate: %w", err)
+		}
+		writeUpdateCache(cache, endpoint, latest)
+	}
+
+	if compareVersion(latest, current) <= 0 {
+		return "", nil
+	}
+	return fmt.Sprintf(Translate("a newer version of %s is available: %s (you have %s)"),
+		Program(), latest, current), nil
+}
+
+func updateCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, Program(), "update-check")
+}
+
+// The cache file is "[unix time] [endpoint]\n[version]\n".
+func readUpdateCache(path, endpoint string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	head, latest, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+	ts, ep, _ := strings.Cut(head, " ")
+	t, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || ep != endpoint || latest == "" || time.Since(time.Unix(t, 0)) > UpdateCheckInterval {
+		return "", false
+	}
+	return latest, true
+}
+
+func writeUpdateCache(path, endpoint, latest string) {
+	if path == "" {
+		return
+	}
+	// Errors are ignored; we'll just check again next time.
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, []byte(fmt.Sprintf("%d %s\n%s\n", time.Now().Unix(), endpoint, latest)), 0o644)
+}
+
+func fetchLatest(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "github.com" {
+		p := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(p) < 2 {
+			return "", fmt.Errorf("not a GitHub repository: %q", endpoint)
+		}
+		endpoint = "https://api.github.com/repos/" + p[0] + "/" + p[1] + "/releases/latest"
+	}
+
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Get(endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", endpoint, resp.Status)
+	}
+
+	body = []byte(strings.TrimSpace(string(body)))
+	if len(body) > 0 && body[0] == '{' {
+		var rel struct {
+			TagName string `json:"tag_name"`
+		}
+		if err := json.Unmarshal(body, &rel); err != nil {
+			return "", fmt.Errorf("%s: %w", endpoint, err)
+		}
+		body = []byte(rel.TagName)
+	}
+	latest, _, _ := strings.Cut(string(body), "\n")
+	if latest = strings.TrimSpace(latest); latest == "" {
+		return "", fmt.Errorf("%s: no version in response", endpoint)
+	}
+	return latest, nil
+}
@@ -0,0 +1,79 @@
+package zli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCheckUpdate(t *testing.T) {
+	Test(t)
+	SetProgram("prog")
+	defer SetProgram("")
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+
+	var (
+		reqs int
+		resp = "v1.2.0\n"
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs++
+		fmt.Fprint(w, resp)
+	}))
+	defer srv.Close()
+
+	{
+		have, err := CheckUpdate("v1.1.0", srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "a newer version of prog is available: v1.2.0 (you have v1.1.0)"
+		if have != want {
+			t.Errorf("\nhave: %q\nwant: %q", have, want)
+		}
+	}
+
+	// Cached.
+	resp = "v1.3.0"
+	for _, v := range []string{"v1.2.0", "v1.2.1"} {
+		have, err := CheckUpdate(v, srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have != "" {
+			t.Errorf("%s: %q", v, have)
+		}
+	}
+	if reqs != 1 {
+		t.Errorf("reqs=%d", reqs)
+	}
+	if _, err := os.Stat(updateCachePath()); err != nil {
+		t.Error(err)
+	}
+
+	// JSON, and the cache is per-endpoint.
+	resp = `{"tag_name": "v2.0.0"}`
+	have, err := CheckUpdate("v1.2.0", srv.URL+"/json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(have, "v2.0.0") || reqs != 2 {
+		t.Errorf("reqs=%d; %q", reqs, have)
+	}
+
+	// Development version.
+	if have, err := CheckUpdate("dev", srv.URL+"/dev"); have != "" || err != nil || reqs != 2 {
+		t.Errorf("reqs=%d; %q; %v", reqs, have, err)
+	}
+
+	// Error.
+	resp = ""
+	if _, err := CheckUpdate("v1.0.0", srv.URL+"/empty"); err == nil {
+		t.Error("err is nil")
+	}
+}
@@ -1,10 +1,10 @@
 package zli
 
 import (
-	"os"
-	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Formatting flags for Usage.
@@ -22,7 +22,7 @@ const (
 	//   Header:
 	//
 	// A header must be at the start of the line, preceded by a blank line, and
-	// end with a double colon (:).
+	// end with a double colon (:). The header is passed through Translate().
 	UsageHeaders = 2
 
 	// UsageFlags formats flags in the form of:
@@ -31,15 +31,60 @@ const (
 	//   -flag
 	//   -flag=foo
 	//   -flag=[foo]
+	//
+	// Flags inside a `literal span` aren't formatted, and a flag can be escaped
+	// with a backslash: "negative \-1 values" is printed as "negative -1
+	// values". The backticks are printed as-is.
 	UsageFlags = 4
 
-	// UsageProgram replaces "%(prog)" with filepath.Base(os.Args[0]).
+	// UsageProgram replaces "%(prog)" with Program().
 	UsageProgram = 8
+
+	// UsageAlign aligns flag descriptions in a second column:
+	//
+	//   -h, -help    Show this help.
+	//   -o FILE      Write output to FILE; long descriptions are
+	//                wrapped to the terminal width.
+	//
+	// A flag line starts with whitespace and "-", and has at least two spaces
+	// between the flag and the description. Lines that follow it and are
+	// indented deeper than the flag are a continuation of the description.
+	// Consecutive flag lines are aligned together.
+	UsageAlign = 16
+
+	// UsageExamples formats example commands in the form of:
+	//
+	//   % prog -flag arg
+	//   $ prog -flag arg
+	//
+	// An example must be indented and start with "% " or "$ ". The command is
+	// formatted with FormatExample and the program name with
+	// FormatExampleProgram. Any lines directly after it with at least the same
+	// indentation are considered output of the example, until a blank line or
+	// a line starting with a flag. Flags are never formatted inside examples or
+	// their output.
+	UsageExamples = 32
+
+	// UsageEnv formats environment variables in the form of:
+	//
+	//   $VAR
+	//   ${VAR}
+	//   SOME_VAR
+	//
+	// Without a leading "$" it must be all uppercase and contain at least one
+	// underscore.
+	UsageEnv = 64
+
+	// UsageVersion replaces "%(version)" with Version().
+	UsageVersion = 128
 )
 
 var (
-	reHeader = regexp.MustCompile(`^\w[\w -]+:$`)
-	reFlags  = regexp.MustCompile(`\B-{1,2}[a-z0-9=-]+\b`)
+	reHeader  = regexp.MustCompile(`^\w[\w -]+:$`)
+	reFlags   = regexp.MustCompile(`\B-{1,2}[a-z0-9=-]+\b`)
+	reAlign   = regexp.MustCompile(`^( +)(-\S.*?) {2,}(\S.*)$`)
+	reExample = regexp.MustCompile(`^(\s+)([$%] )(\S+)(.*)$`)
+	reEnv     = regexp.MustCompile(`\$\{\w+\}|\$\w+\b|\b[A-Z][A-Z0-9]*(?:_[A-Z0-9]+)+\b`)
 )
 
 var (
@@ -48,8 +93,47 @@ var (
 
 	// FormatFlag is the formatting to apply for a flag.
 	FormatFlag = Underline
+
+	// FormatEnv is the formatting to apply for an environment variable.
+	FormatEnv = Cyan
+
+	// FormatExample is the formatting to apply for an example command.
+	FormatExample = Dim
+
+	// FormatExampleProgram is the formatting to apply for the program name in
+	// an example command.
+	FormatExampleProgram = Bold
 )
 
+// UsageFormatter formats all parts of a usage message that match a regular
+// expression.
+type UsageFormatter struct {
+	Match *regexp.Regexp
+
+	// Color to apply to the matched text; this is ignored if Format is set.
+	Color Color
+
+	// Format the matched text.
+	Format func(match string) string
+}
+
+func (f UsageFormatter) format(line string) string {
+	if f.Format != nil {
+		return f.Match.ReplaceAllStringFunc(line, f.Format)
+	}
+	return f.Match.ReplaceAllStringFunc(line, func(m string) string { return Colorize(m, f.Color) })
+}
+
+// UsageFormatters are applied by Usage() on every line of a usage message, in
+// order. They're applied after headers are formatted, and before flags are
+// formatted. Lines in examples aren't formatted. For example to format
+// placeholders such as "<file>" and URLs:
+//
+//	zli.UsageFormatters = append(zli.UsageFormatters,
+//	    zli.UsageFormatter{Match: regexp.MustCompile(`<\w+>`), Color: zli.Italic},
+//	    zli.UsageFormatter{Match: regexp.MustCompile(`https?://\S+`), Color: zli.Blue})
+var UsageFormatters []UsageFormatter
+
 // Usage applies some formatting to a usage message. See the Usage* constants.
 func Usage(opts int, text string) string {
 	if opts&UsageTrim != 0 {
@@ -57,22 +141,327 @@ func Usage(opts int, text string) string {
 	}
 
 	if opts&UsageProgram != 0 {
-		text = strings.ReplaceAll(text, "%(prog)", filepath.Base(os.Args[0]))
+		text = strings.ReplaceAll(text, "%(prog)", Program())
+	}
+	if opts&UsageVersion != 0 {
+		text = strings.ReplaceAll(text, "%(version)", Version())
+	}
+
+	if opts&UsageAlign != 0 {
+		text = usageAlign(text)
 	}
 
 	if opts&UsageHeaders != 0 {
 		split := strings.Split(text, "\n")
 		for i := range split {
 			if reHeader.MatchString(split[i]) && (i == 0 || split[i-1] == "") {
-				split[i] = Colorize(split[i], FormatHeader)
+				split[i] = Colorize(Translate(split[i]), FormatHeader)
 			}
 		}
 		text = strings.Join(split, "\n")
 	}
 
-	if opts&UsageFlags != 0 {
-		text = reFlags.ReplaceAllString(text, Colorize(`$0`, FormatFlag))
+	split := strings.Split(text, "\n")
+	ex := make([]int, len(split))
+	if opts&UsageExamples != 0 {
+		ex = exampleLines(split)
 	}
+	for i := range split {
+		switch ex[i] {
+		case 1:
+			m := reExample.FindStringSubmatch(split[i])
+			split[i] = m[1] + Colorize(m[2], FormatExample) +
+				Colorize(m[3], FormatExampleProgram) + Colorize(m[4], FormatExample)
+		case 0:
+			for _, f := range UsageFormatters {
+				split[i] = f.format(split[i])
+			}
+			if opts&UsageEnv != 0 {
+				split[i] = reEnv.ReplaceAllString(split[i], Colorize(`$0`, FormatEnv))
+			}
+			if opts&UsageFlags != 0 {
+				split[i] = formatFlags(split[i])
+			}
+		}
+	}
+	text = strings.Join(split, "\n")
 
 	return text
 }
+
+// Usage generates a list of all flags, grouped by Group(), which can be used in
+// a usage message:
+//
+//	Options:
+//	    -v, -verbose
+//	    -o, -output FILE    Write to FILE. (default: out.txt)
+//
+//	Output options:
+//	    -json               Output as JSON.
+//
+// The description and placeholder are set with Help(). Descriptions are
+// aligned and wrapped to the terminal width, so UsageAlign isn't needed. Use
+// zli.Usage() to add colors:
+//
+//	zli.Usage(zli.UsageHeaders|zli.UsageFlags, f.Usage())
+//
+// Groups are listed in the order the first flag in that group was added, and
+// flags in the order they were added. The "Options" group for flags without a
+// group is always listed first.
+//
+// Default values are shown unless it's the zero value or the flag is marked
+// with HideDefault() or Secret(). Flags added with Hidden() and Deprecated()
+// names aren't listed.
+func (f *Flags) Usage() string {
+	type line struct{ flag, desc string }
+	var (
+		groups = []string{""}
+		lines  = map[string][]line{}
+	)
+	for _, flag := range f.flags {
+		if flag.meta.hidden {
+			continue
+		}
+		names := make([]string, 0, len(flag.names))
+		for _, n := range flag.names {
+			if _, ok := f.deprecated[n]; !ok {
+				names = append(names, "-"+n)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		g := flag.meta.group
+		if _, ok := lines[g]; !ok && g != "" {
+			groups = append(groups, g)
+		}
+
+		l := line{flag: strings.Join(names, ", "), desc: flag.meta.help}
+		if ph := flag.meta.placeholder; ph != "" {
+			if isOptional(flag) {
+				ph = "[" + ph + "]"
+			}
+			l.flag += " " + ph
+		}
+		if d := flag.meta.defaultString(); d != "" {
+			if l.desc != "" {
+				l.desc += " "
+			}
+			l.desc += "(" + Translate("default") + ": " + d + ")"
+		}
+		lines[g] = append(lines[g], l)
+	}
+
+	var (
+		b     strings.Builder
+		width = usageWidth()
+	)
+	for _, g := range groups {
+		if len(lines[g]) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		name := g
+		if name == "" {
+			name = Translate("Options")
+		}
+		b.WriteString(name + ":\n")
+
+		col := 0
+		for _, l := range lines[g] {
+			if l.desc != "" {
+				col = max(col, utf8.RuneCountInString(l.flag))
+			}
+		}
+		col += 4 + 2
+		for _, l := range lines[g] {
+			b.WriteString("    " + l.flag)
+			if l.desc != "" {
+				b.WriteString(strings.Repeat(" ", col-4-utf8.RuneCountInString(l.flag)))
+				for i, d := range wrap(l.desc, max(width-col, 20)) {
+					if i > 0 {
+						b.WriteString("\n" + strings.Repeat(" ", col))
+					}
+					b.WriteString(d)
+				}
+			}
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// defaultString gets the default value to display in Usage(), or "" if it
+// shouldn't be displayed.
+func (m flagMeta) defaultString() string {
+	if m.secret || m.hideDefault {
+		return ""
+	}
+	switch d := m.def.(type) {
+	case *bool:
+		if *d {
+			return "true"
+		}
+	case *string:
+		return *d
+	case *int:
+		if *d != 0 {
+			return strconv.Itoa(*d)
+		}
+	case *int32:
+		if *d != 0 {
+			return strconv.FormatInt(int64(*d), 10)
+		}
+	case *int64:
+		if *d != 0 {
+			return strconv.FormatInt(*d, 10)
+		}
+	case *float64:
+		if *d != 0 {
+			return strconv.FormatFloat(*d, 'f', -1, 64)
+		}
+	case *[]string:
+		return strings.Join(*d, ", ")
+	case *[]int:
+		l := make([]string, len(*d))
+		for i := range *d {
+			l[i] = strconv.Itoa((*d)[i])
+		}
+		return strings.Join(l, ", ")
+	}
+	return ""
+}
+
+// formatFlags formats all flags in line, except those escaped with a backslash
+// or inside a `literal span`.
+func formatFlags(line string) string {
+	var (
+		b     strings.Builder
+		parts = strings.Split(line, "`")
+	)
+	b.Grow(len(line))
+	for i, p := range parts {
+		if i > 0 {
+			b.WriteByte('`')
+		}
+		if i%2 == 1 && i < len(parts)-1 {
+			b.WriteString(p)
+			continue
+		}
+
+		prev := 0
+		for _, m := range reFlags.FindAllStringIndex(p, -1) {
+			if m[0] > 0 && p[m[0]-1] == '\\' {
+				b.WriteString(p[prev : m[0]-1])
+				b.WriteString(p[m[0]:m[1]])
+			} else {
+				b.WriteString(p[prev:m[0]])
+				b.WriteString(Colorize(p[m[0]:m[1]], FormatFlag))
+			}
+			prev = m[1]
+		}
+		b.WriteString(p[prev:])
+	}
+	return b.String()
+}
+
+// exampleLines marks example commands as 1 and their output as 2. The output
+// ends at a blank line, a line that's indented less than the example, or a line
+// starting with a "-" (e.g. a list of flags directly after the examples).
+func exampleLines(lines []string) []int {
+	ex := make([]int, len(lines))
+	indent := -1
+	for i, l := range lines {
+		if m := reExample.FindStringSubmatch(l); m != nil {
+			ex[i], indent = 1, len(m[1])
+			continue
+		}
+		t := strings.TrimLeft(l, " \t")
+		if indent > -1 && t != "" && t[0] != '-' && len(l)-len(t) >= indent {
+			ex[i] = 2
+			continue
+		}
+		indent = -1
+	}
+	return ex
+}
+
+// usageWidth gets the width to wrap usage text at.
+func usageWidth() int {
+	if fp, ok := Stdout.(interface{ Fd() uintptr }); ok {
+		if w, _, err := TerminalSize(fp.Fd()); err == nil && w > 0 {
+			return w
+		}
+	}
+	return 80
+}
+
+func usageAlign(text string) string {
+	width := usageWidth()
+
+	type pair struct{ indent, flag, desc string }
+	var (
+		lines = strings.Split(text, "\n")
+		out   = make([]string, 0, len(lines))
+	)
+	for i := 0; i < len(lines); {
+		if !reAlign.MatchString(lines[i]) {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+
+		var block []pair
+		for ; i < len(lines); i++ {
+			if m := reAlign.FindStringSubmatch(lines[i]); m != nil {
+				block = append(block, pair{m[1], m[2], m[3]})
+				continue
+			}
+			l, last := strings.TrimLeft(lines[i], " "), &block[len(block)-1]
+			if l == "" || len(lines[i])-len(l) <= len(last.indent) {
+				break
+			}
+			last.desc += " " + strings.TrimSpace(l)
+		}
+
+		col := 0
+		for _, p := range block {
+			col = max(col, len(p.indent)+utf8.RuneCountInString(p.flag))
+		}
+		col += 2
+		for _, p := range block {
+			pad := strings.Repeat(" ", col-len(p.indent)-utf8.RuneCountInString(p.flag))
+			for j, l := range wrap(p.desc, max(width-col, 20)) {
+				if j == 0 {
+					out = append(out, p.indent+p.flag+pad+l)
+				} else {
+					out = append(out, strings.Repeat(" ", col)+l)
+				}
+			}
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrap text on word boundaries so every line is at most width characters,
+// unless a single word is longer.
+func wrap(text string, width int) []string {
+	var (
+		lines []string
+		line  string
+	)
+	for _, w := range strings.Fields(text) {
+		if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(w) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	return append(lines, line)
+}
@@ -2,6 +2,7 @@ package zli_test
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -41,6 +42,48 @@ func TestUsage(t *testing.T) {
 			`,
 			"\nHello, \x1b[4m-flag\x1b[0m\n\x1b[4m-flag\x1b[0m\n\x1b[4m-flag-name\x1b[0m, \x1b[4m--flag\x1b[0m\n\x1b[4m-flag=foo\x1b[0m\n\nhyphen-word.\n",
 		},
+
+		{
+			zli.UsageFlags | zli.UsageExamples,
+			`
+				Use -flag:
+				    % prog -flag x
+				    output -o
+				-flag
+			`,
+			"\nUse \x1b[4m-flag\x1b[0m:\n    \x1b[2m% \x1b[0m\x1b[1mprog\x1b[0m\x1b[2m -flag x\x1b[0m\n    output -o\n\x1b[4m-flag\x1b[0m\n",
+		},
+		{
+			zli.UsageFlags | zli.UsageExamples,
+			`
+				    % prog -x
+				      output -o
+				    -flag    Desc -o
+
+				    text -o
+			`,
+			"\n    \x1b[2m% \x1b[0m\x1b[1mprog\x1b[0m\x1b[2m -x\x1b[0m\n      output -o\n    \x1b[4m-flag\x1b[0m    Desc \x1b[4m-o\x1b[0m\n\n    text \x1b[4m-o\x1b[0m\n",
+		},
+
+		{
+			zli.UsageFlags,
+			"Use `-flag`, not -other.\nNegative \\-1 values, `unclosed -x",
+			"Use `-flag`, not \x1b[4m-other\x1b[0m.\nNegative -1 values, `unclosed \x1b[4m-x\x1b[0m",
+		},
+		{
+			zli.UsageFlags,
+			"`-a` and `-b` but -c; ``",
+			"`-a` and `-b` but \x1b[4m-c\x1b[0m; ``",
+		},
+
+		{
+			zli.UsageEnv,
+			`
+				Uses $PAGER, ${EDITOR}, and ZLI_COLOR_MODE.
+				But not UPPER or lower_case or $ alone.
+			`,
+			"\nUses \x1b[36m$PAGER\x1b[0m, \x1b[36m${EDITOR}\x1b[0m, and \x1b[36mZLI_COLOR_MODE\x1b[0m.\nBut not UPPER or lower_case or $ alone.\n",
+		},
 	}
 
 	for i, tt := range tests {
@@ -56,3 +99,165 @@ func TestUsage(t *testing.T) {
 		})
 	}
 }
+
+func TestUsageAlign(t *testing.T) {
+	save := zli.TerminalSize
+	zli.TerminalSize = func(uintptr) (int, int, error) { return 40, 25, nil }
+	defer func() { zli.TerminalSize = save }()
+
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"no flags  here", "no flags  here"},
+		{
+			"Options:\n  -h, -help  Show help.\n  -o FILE     Output file.\n\nText\n",
+			"Options:\n  -h, -help  Show help.\n  -o FILE    Output file.\n\nText\n",
+		},
+		{
+			"  -a  A long description that should be wrapped to the terminal width.\n  -bb  Short.",
+			"  -a   A long description that should be\n       wrapped to the terminal width.\n  -bb  Short.",
+		},
+		{
+			"  -a  Description\n        which continues\n  -b  Next.\nNot a continuation",
+			"  -a  Description which continues\n  -b  Next.\nNot a continuation",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got := zli.Usage(zli.UsageAlign, tt.in)
+			if got != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q\n\n%s", got, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUsageFormatters(t *testing.T) {
+	zli.Test(t, zli.TestColor(true))
+	zli.UsageFormatters = []zli.UsageFormatter{
+		{Match: regexp.MustCompile(`<\w+>`), Color: zli.Italic},
+		{Match: regexp.MustCompile(`https?://\S+`), Format: func(m string) string { return "[" + m + "]" }},
+	}
+	defer func() { zli.UsageFormatters = nil }()
+
+	got := zli.Usage(zli.UsageFlags, "-f <file>  See https://example.com\n    % prog <file>")
+	want := "\x1b[4m-f\x1b[0m \x1b[3m<file>\x1b[0m  See [https://example.com]\n    % prog \x1b[3m<file>\x1b[0m"
+	if got != want {
+		t.Errorf("\ngot:  %q\nwant: %q", got, want)
+	}
+
+	got = zli.Usage(zli.UsageExamples, "    % prog <file>")
+	want = "    \x1b[2m% \x1b[0m\x1b[1mprog\x1b[0m\x1b[2m <file>\x1b[0m"
+	if got != want {
+		t.Errorf("\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestUsageProgram(t *testing.T) {
+	got := zli.Usage(zli.UsageProgram|zli.UsageVersion, "%(prog) version %(version)")
+	want := "zli.test version " + zli.Version()
+	if got != want {
+		t.Errorf("\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestFlagsUsage(t *testing.T) {
+	save := zli.TerminalSize
+	zli.TerminalSize = func(uintptr) (int, int, error) { return 60, 25, nil }
+	defer func() { zli.TerminalSize = save }()
+
+	f := zli.NewFlags([]string{"prog"})
+	f.Bool(false, "v", "verbose")
+	f.Group("Output options")
+	f.Bool(false, "json")
+	f.Group("Network options")
+	f.Int(8080, "port")
+	f.String("hunter2", "password").Secret()
+	f.String("/home/martin", "home").HideDefault()
+	f.Group("Output options")
+	f.String("", "o", "-output")
+	f.Float64(1.5, "scale")
+	f.StringList([]string{"a", "b"}, "exclude")
+	f.IntList([]int{1, 2}, "skip")
+	f.Group("")
+	f.Bool(false, "h", "help")
+	f.Bool(true, "b")
+	f.Hidden().Bool(false, "debug")
+	f.Hidden().Optional().String("x", "trace")
+	f.Bool(false, "q")
+	f.Group("Help")
+	f.Bool(false, "n").Help("Dry run.", "")
+	f.String("", "f", "file").Help("Read from FILE.", "FILE")
+	f.Optional().String("auto", "color").Help("When to use colors.", "WHEN")
+	f.Int(0, "depth")
+	f.IntCounter(0, "V").Help("Show more output; can be given more than once, and it will show even more output.", "")
+
+	want := `
+Options:
+    -v, -verbose
+    -h, -help
+    -b  (default: true)
+    -q
+
+Output options:
+    -json
+    -o, -output
+    -scale    (default: 1.5)
+    -exclude  (default: a, b)
+    -skip     (default: 1, 2)
+
+Network options:
+    -port  (default: 8080)
+    -password
+    -home
+
+Help:
+    -n              Dry run.
+    -f, -file FILE  Read from FILE.
+    -color [WHEN]   When to use colors. (default: auto)
+    -depth
+    -V              Show more output; can be given more than
+                    once, and it will show even more output.
+`[1:]
+	if have := f.Usage(); have != want {
+		t.Errorf("\nhave:\n%s\nwant:\n%s", have, want)
+	}
+
+	t.Run("parsed", func(t *testing.T) {
+		f := zli.NewFlags([]string{"prog", "-debug", "-trace"})
+		debug := f.Hidden().Bool(false, "debug")
+		trace := f.Hidden().Optional().String("x", "trace")
+		err := f.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !debug.Bool() || !trace.Set() || trace.String() != "x" {
+			t.Errorf("%t %t %q", debug.Bool(), trace.Set(), trace.String())
+		}
+		if have := f.Usage(); have != "" {
+			t.Errorf("%q", have)
+		}
+	})
+
+	t.Run("not a terminal", func(t *testing.T) {
+		zli.Test(t)
+		f := zli.NewFlags([]string{"prog"})
+		f.Bool(false, "v").Help(strings.Repeat("word ", 20), "")
+
+		want := "Options:\n" +
+			"    -v  word word word word word word word word word word word word word word\n" +
+			"        word word word word word word\n"
+		if have := f.Usage(); have != want {
+			t.Errorf("\nhave:\n%s\nwant:\n%s", have, want)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		f := zli.NewFlags([]string{"prog"})
+		if have := f.Usage(); have != "" {
+			t.Errorf("%q", have)
+		}
+	})
+}
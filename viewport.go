@@ -0,0 +1,167 @@
+package zli
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Viewport is a rectangular region of the screen. Text printed to it is
+// clipped to the region, so that several viewports can be used side-by-side
+// without keeping track of coordinates:
+//
+//	left, right := zli.NewViewport(1, 1, 40, 24), zli.NewViewport(1, 41, 40, 24)
+//	left.Println("Files:")
+//	right.Println("Preview:")
+//
+// Text is printed at the viewport's cursor, which starts at the top-left
+// corner. Lines that are too long are clipped, or wrapped if Wrap is set, and
+// anything below the bottom is discarded. Escape sequences for colors are
+// passed through and don't count towards the width; escape sequences outside
+// the viewport are written with the next text that is inside it, or at the
+// end without moving the cursor.
+//
+// This moves the terminal's cursor, but doesn't keep track of it: use To() or
+// Move() to position it after printing to a viewport.
+type Viewport struct {
+	Wrap bool // Wrap long lines, instead of clipping them.
+
+	t                       Term
+	row, col, width, height int
+	x, y                    int // Cursor in the viewport, 0-based.
+}
+
+// NewViewport creates a new Viewport at the given position, which writes to
+// Stdout.
+//
+// The top-left corner of the screen is 1, 1.
+func NewViewport(row, col, width, height int) *Viewport {
+	return stdTerm().Viewport(row, col, width, height)
+}
+
+// Viewport is like NewViewport(), but writes to t.
+func (t Term) Viewport(row, col, width, height int) *Viewport {
+	return &Viewport{t: t, row: max(row, 1), col: max(col, 1),
+		width: max(width, 0), height: max(height, 0)}
+}
+
+// Size gets the dimensions of the viewport.
+func (v *Viewport) Size() (width, height int) { return v.width, v.height }
+
+// Cursor gets the cursor position in the viewport; the top-left corner is 1,
+// 1. The row is larger than the height if the cursor is below the bottom.
+func (v *Viewport) Cursor() (row, col int) { return v.y + 1, v.x + 1 }
+
+// To sets the cursor position in the viewport and prints the text; the
+// top-left corner is 1, 1.
+func (v *Viewport) To(row, col int, text string, a ...any) {
+	v.y, v.x = max(row, 1)-1, max(col, 1)-1
+	v.Printf(text, a...)
+}
+
+// Clear fills the viewport with spaces and sets the cursor to the top-left
+// corner.
+func (v *Viewport) Clear() {
+	blank := strings.Repeat(" ", v.width)
+	for i := 0; i < v.height; i++ {
+		v.t.To(v.row+i, v.col, blank)
+	}
+	v.x, v.y = 0, 0
+}
+
+// Write writes p to the viewport.
+func (v *Viewport) Write(p []byte) (int, error) {
+	v.print(string(p))
+	return len(p), nil
+}
+
+// Print writes the text to the viewport, like fmt.Print().
+func (v *Viewport) Print(a ...any) { v.print(fmt.Sprint(a...)) }
+
+// Println writes the text to the viewport, like fmt.Println().
+func (v *Viewport) Println(a ...any) { v.print(fmt.Sprintln(a...)) }
+
+// Printf writes the text to the viewport, like fmt.Printf().
+func (v *Viewport) Printf(format string, a ...any) {
+	if len(a) == 0 {
+		v.print(format)
+		return
+	}
+	v.print(fmt.Sprintf(format, a...))
+}
+
+func (v *Viewport) print(text string) {
+	var (
+		seg  strings.Builder
+		segX int
+		esc  strings.Builder // Escapes outside the viewport.
+	)
+	flush := func() {
+		if seg.Len() > 0 {
+			v.t.To(v.row+v.y, v.col+segX, "%s", seg.String())
+			seg.Reset()
+		}
+	}
+	add := func(s string) {
+		if seg.Len() == 0 {
+			segX = v.x
+			seg.WriteString(esc.String())
+			esc.Reset()
+		}
+		seg.WriteString(s)
+	}
+
+	for i := 0; i < len(text); {
+		switch c := text[i]; c {
+		case '\x1b':
+			// Don't move the cursor for just an escape, as it may be outside
+			// the viewport.
+			n := escapeLen(text[i:])
+			if seg.Len() > 0 {
+				seg.WriteString(text[i : i+n])
+			} else {
+				esc.WriteString(text[i : i+n])
+			}
+			i += n
+			continue
+		case '\n':
+			flush()
+			v.x = 0
+			v.y++
+		case '\r':
+			flush()
+			v.x = 0
+		default:
+			_, n := utf8.DecodeRuneInString(text[i:])
+			if v.x >= v.width && v.Wrap {
+				flush()
+				v.x = 0
+				v.y++
+			}
+			if v.x < v.width && v.y < v.height {
+				add(text[i : i+n])
+			}
+			v.x++
+			i += n
+			continue
+		}
+		i++
+	}
+	flush()
+	if esc.Len() > 0 {
+		fmt.Fprint(v.t.w, esc.String())
+	}
+}
+
+// escapeLen gets the length of the escape sequence at the start of s.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[1] != '[' {
+		return min(len(s), 2)
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= '@' && s[i] <= '~' {
+			return i + 1
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,85 @@
+package zli_test
+
+import (
+	"fmt"
+	"testing"
+
+	"zgo.at/zli"
+)
+
+func TestViewport(t *testing.T) {
+	s := zli.NewTestScreen(20, 6)
+	term := zli.NewTerm(s)
+
+	left, right := term.Viewport(2, 1, 8, 3), term.Viewport(2, 10, 6, 3)
+	right.Wrap = true
+
+	left.Println("Files:")
+	left.Println("a-very-long-name.txt")
+	left.Print("b.txt\nc.txt\nd.txt")
+	right.Print("Some text that is wrapped")
+
+	want := "\n" +
+		"Files:   Some t\n" +
+		"a-very-l ext th\n" +
+		"b.txt    at is"
+	if have := s.String(); have != want {
+		t.Errorf("\nhave:\n%s\nwant:\n%s", have, want)
+	}
+
+	if r, c := left.Cursor(); r != 5 || c != 6 {
+		t.Errorf("left cursor: %d, %d", r, c)
+	}
+	if w, h := left.Size(); w != 8 || h != 3 {
+		t.Errorf("size: %d, %d", w, h)
+	}
+
+	left.Clear()
+	left.To(2, 3, "%s", "x")
+	right.To(3, 1, "")
+	fmt.Fprint(right, "over")
+	want = "\n" +
+		"         Some t\n" +
+		"  x      ext th\n" +
+		"         overs"
+	if have := s.String(); have != want {
+		t.Errorf("\nhave:\n%s\nwant:\n%s", have, want)
+	}
+}
+
+func TestViewportColor(t *testing.T) {
+	s := zli.NewTestScreen(10, 2)
+	v := zli.NewTerm(s).Viewport(1, 1, 4, 2)
+	v.Print("\x1b[31mredred\x1b[0m!\n\x1b[1mbold")
+
+	if have := s.String(); have != "redr\nbold" {
+		t.Errorf("\n%s", have)
+	}
+	if a := s.Attr(1, 1); a != "31" {
+		t.Errorf("attr 1: %q", a)
+	}
+	if a := s.Attr(2, 1); a != "1" {
+		t.Errorf("attr 2: %q", a)
+	}
+}
+
+func TestViewportColorClipped(t *testing.T) {
+	s := zli.NewTestScreen(10, 3)
+	v := zli.NewTerm(s).Viewport(1, 1, 4, 2)
+	v.Print("abcd")
+	v.Print("ef\x1b[31mgh\n\x1b[0mij\nkl\x1b[32m")
+
+	if have := s.String(); have != "abcd\nij" {
+		t.Errorf("\n%s", have)
+	}
+	if a := s.Attr(2, 1); a != "" {
+		t.Errorf("attr: %q", a)
+	}
+	if r, c := s.Cursor(); r != 2 || c != 3 {
+		t.Errorf("cursor: %d, %d", r, c)
+	}
+	fmt.Fprint(s, "x")
+	if a := s.Attr(2, 3); a != "32" {
+		t.Errorf("attr: %q", a)
+	}
+}
@@ -8,62 +8,262 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 var (
-	Exit   func(int) = os.Exit
+	Exit   func(int) = defaultExit
 	Stdin  io.Reader = os.Stdin
 	Stdout io.Writer = os.Stdout
 	Stderr io.Writer = os.Stderr
 )
 
-// Program gets the program name from argv.
+var (
+	atExitMu sync.Mutex
+	atExit   []*func()
+)
+
+// AtExit registers a function to run before the program exits with Exit(),
+// Fatalf(), or F(). This is useful for cleanup that would otherwise be skipped
+// because deferred functions aren't run on exit, such as restoring the
+// terminal state or removing temporary files.
+//
+// Functions are run in reverse order, similar to defer. They're not run if
+// zli.Exit is replaced (e.g. with Test()), or if main() returns normally; use
+// RunAtExit() for that:
+//
+//	func main() {
+//	    defer zli.RunAtExit()
+//	    // ...
+//	}
+func AtExit(fn func()) { addAtExit(fn) }
+
+// addAtExit registers fn with AtExit(), returning a function to remove it
+// again.
+func addAtExit(fn func()) func() {
+	p := &fn
+	atExitMu.Lock()
+	defer atExitMu.Unlock()
+	atExit = append(atExit, p)
+
+	return func() {
+		atExitMu.Lock()
+		defer atExitMu.Unlock()
+		for i := range atExit {
+			if atExit[i] == p {
+				atExit = append(atExit[:i], atExit[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// RunAtExit runs all functions registered with AtExit() and clears the list.
+func RunAtExit() {
+	atExitMu.Lock()
+	fns := atExit
+	atExit = nil
+	atExitMu.Unlock()
+
+	for i := len(fns) - 1; i >= 0; i-- {
+		(*fns[i])()
+	}
+}
+
+func defaultExit(c int) {
+	RunAtExit()
+	os.Exit(c)
+}
+
+// SetProgram sets the program name returned by Program(); use "" to use the
+// default.
+func SetProgram(name string) { progname = name }
+
+// Translate is called for all user-facing text zli generates, such as flag
+// parsing errors, ShiftCommand() errors, and headers formatted by Usage().
+//
+// The text is passed before any formatting is applied; for example the error
+// for an unknown flag calls Translate("unknown flag: %q"). The default is to
+// return the text as-is.
+var Translate = func(s string) string { return s }
+
+// Program gets the program name.
+//
+// This is the name set with SetProgram() or the zgo.at/zli.progname variable
+// at build time (see PrintVersion()), or filepath.Base(os.Args[0]) if neither
+// is set. It's used by Errorf(), Usage(), PrintVersion(), etc.
 func Program() string {
+	if progname != "" {
+		return progname
+	}
 	if len(os.Args) == 0 {
 		return ""
 	}
 	return filepath.Base(os.Args[0])
 }
 
-// Error prints an error message to stderr prepended with the program name and
-// with a newline appended.
-func Errorf(s any, args ...any) {
-	prog := Program()
-	if prog != "" {
-		prog += ": "
+var (
+	// ErrorPrefix is printed before every message printed with Errorf(),
+	// Fatalf(), Warnf(), Infof(), and Debugf(); "%(prog)" is replaced with
+	// Program().
+	//
+	// Set to "" to print only the message, or to something like "%(prog):
+	// error: " for GNU-style messages.
+	ErrorPrefix = "%(prog): "
+
+	// FormatErrorPrefix is the formatting to apply to ErrorPrefix.
+	FormatErrorPrefix = Reset
+)
+
+func errorPrefix() string {
+	p := ErrorPrefix
+	if prog := Program(); prog == "" {
+		p = strings.ReplaceAll(p, "%(prog): ", "")
+	} else {
+		p = strings.ReplaceAll(p, "%(prog)", prog)
 	}
+	if p == "" {
+		return ""
+	}
+	return colorizeStderr(p, FormatErrorPrefix)
+}
 
+// Error prints an error message to stderr prepended with ErrorPrefix and with
+// a newline appended.
+func Errorf(s any, args ...any) { fprintMsg(Stderr, errorPrefix(), s, args...) }
+
+func fprintMsg(w io.Writer, prefix string, s any, args ...any) {
 	switch ss := s.(type) {
 	case string:
-		fmt.Fprintf(Stderr, prog+ss+"\n", args...)
+		fmt.Fprintf(w, prefix+ss+"\n", args...)
 	case []byte:
-		fmt.Fprintf(Stderr, prog+string(ss)+"\n", args...)
+		fmt.Fprintf(w, prefix+string(ss)+"\n", args...)
 	case error:
 		if len(args) > 0 {
-			fmt.Fprintf(Stderr, "%s%s %v\n", prog, ss.Error(), args)
+			fmt.Fprintf(w, "%s%s %v\n", prefix, ss.Error(), args)
 		} else {
-			fmt.Fprintln(Stderr, prog+ss.Error())
+			fmt.Fprintln(w, prefix+ss.Error())
 		}
 	default:
 		if len(args) > 0 {
-			fmt.Fprintf(Stderr, prog+"%v %v\n", ss, args)
+			fmt.Fprintf(w, prefix+"%v %v\n", ss, args)
 		} else {
-			fmt.Fprintf(Stderr, prog+"%v\n", ss)
+			fmt.Fprintf(w, prefix+"%v\n", ss)
+		}
+	}
+}
+
+// Print is like fmt.Print, but writes to zli.Stdout.
+func Print(a ...any) { fmt.Fprint(Stdout, a...) }
+
+// Printf is like fmt.Printf, but writes to zli.Stdout.
+func Printf(format string, a ...any) { fmt.Fprintf(Stdout, format, a...) }
+
+// Println is like fmt.Println, but writes to zli.Stdout.
+func Println(a ...any) { fmt.Fprintln(Stdout, a...) }
+
+// Eprint is like fmt.Print, but writes to zli.Stderr.
+func Eprint(a ...any) { fmt.Fprint(Stderr, a...) }
+
+// Eprintf is like fmt.Printf, but writes to zli.Stderr.
+//
+// Unlike Errorf() this doesn't add the program name or a newline.
+func Eprintf(format string, a ...any) { fmt.Fprintf(Stderr, format, a...) }
+
+// Eprintln is like fmt.Println, but writes to zli.Stderr.
+func Eprintln(a ...any) { fmt.Fprintln(Stderr, a...) }
+
+// FormatRule is the formatting to apply to the title in Rule() and
+// RuleCenter().
+var FormatRule = Bold
+
+// Rule prints a horizontal line over the full width of the terminal to Stdout,
+// with an optional title:
+//
+//	── Title ───────────────────────────────
+//
+// The width is 80 if Stdout isn't a terminal.
+func Rule(title string) { rule(title, false) }
+
+// RuleCenter is like Rule(), but centers the title:
+//
+//	─────────────── Title ─────────────────
+func RuleCenter(title string) { rule(title, true) }
+
+func rule(title string, center bool) {
+	width := 80
+	if fp, ok := Stdout.(interface{ Fd() uintptr }); ok {
+		if w, _, err := TerminalSize(fp.Fd()); err == nil && w > 0 {
+			width = w
 		}
 	}
+	if title == "" {
+		fmt.Fprintln(Stdout, strings.Repeat("─", width))
+		return
+	}
+
+	var (
+		n    = utf8.RuneCountInString(title) + 2
+		left = 2
+	)
+	if center {
+		left = max((width-n)/2, 0)
+	}
+	right := max(width-n-left, 0)
+	fmt.Fprintln(Stdout, strings.Repeat("─", left)+" "+Colorize(title, FormatRule)+" "+strings.Repeat("─", right))
 }
 
 // ExitCode is the exit code to use for Fatalf() and F()
+//
+// Use FatalCode() to exit with a different code for just one error.
 var ExitCode = 1
 
-// Fatalf is like Errorf(), but will exit with a code of 1.
+// Exit codes as defined in sysexits.h from BSD.
+//
+// These are more or less a convention, rather than any sort of standard. You
+// don't need to use them, but they're a reasonable choice if you want to be a
+// bit more descriptive than "1" for every error.
+const (
+	ExitOK          = 0  // Successful exit.
+	ExitUsage       = 64 // The command was used incorrectly (wrong flags, arguments, etc.)
+	ExitDataErr     = 65 // The input data was incorrect.
+	ExitNoInput     = 66 // An input file did not exist or was not readable.
+	ExitNoUser      = 67 // The user specified did not exist.
+	ExitNoHost      = 68 // The host specified did not exist.
+	ExitUnavailable = 69 // A service is unavailable.
+	ExitSoftware    = 70 // An internal software error has been detected.
+	ExitOSErr       = 71 // An operating system error has been detected.
+	ExitOSFile      = 72 // Some system file does not exist or has an error.
+	ExitCantCreate  = 73 // A user-specified output file cannot be created.
+	ExitIOErr       = 74 // An error occurred while doing I/O on some file.
+	ExitTempFail    = 75 // Temporary failure; the user is invited to retry.
+	ExitProtocol    = 76 // The remote system returned something that was "not possible".
+	ExitNoPerm      = 77 // Insufficient permission to perform the operation.
+	ExitConfig      = 78 // Something was found in an unconfigured or misconfigured state.
+)
+
+// Fatalf is like Errorf(), but will exit with ExitCode (1 by default).
 func Fatalf(s any, args ...any) {
+	FatalCode(ExitCode, s, args...)
+}
+
+// FatalCode is like Fatalf(), but will exit with the given code rather than
+// ExitCode:
+//
+//	zli.FatalCode(zli.ExitUsage, "need a pattern")
+func FatalCode(code int, s any, args ...any) {
 	Errorf(s, args...)
-	Exit(ExitCode)
+	Exit(code)
 }
 
+// Fatalx is an alias for FatalCode().
+func Fatalx(code int, s any, args ...any) { FatalCode(code, s, args...) }
+
 // F prints the err.Error() to stderr with Errorf() and exits, but it won't do
 // anything if the error is nil.
 func F(err error) {
@@ -72,18 +272,112 @@ func F(err error) {
 	}
 }
 
+// FormatPanic is the formatting to apply to the "panic: " tag printed by
+// Recover().
+var FormatPanic = Red | Bold
+
+// Recover from a panic, print a short error message to stderr, and exit with
+// ExitSoftware. This is friendlier for users than the default Go panic
+// output:
+//
+//	func main() {
+//	    defer zli.Recover()
+//	    // ...
+//	}
+//
+// The stack trace is printed only if the verbosity set with SetVerbose() is 1
+// or higher, or if the ZLI_TRACEBACK environment variable is set.
+//
+// Functions registered with AtExit() are run before exiting, which also
+// restores the terminal if MakeRaw() is active.
+func Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if _, ok := r.(*TestExit); ok {
+		panic(r)
+	}
+
+	fprintMsg(Stderr, errorPrefix()+colorizeStderr(Translate("panic: "), FormatPanic), "%v", r)
+	if verbosity >= 1 || os.Getenv("ZLI_TRACEBACK") != "" {
+		fmt.Fprintf(Stderr, "\n%s", debug.Stack())
+	}
+	Exit(ExitSoftware)
+}
+
+// ExpandPath expands a leading "~" or "~user" to the home directory, and any
+// environment variables in the form of $VAR or ${VAR}:
+//
+//	~/foo/$USER     /home/martin/foo/martin
+//	~root/foo       /root/foo
+//
+// Environment variables that aren't set are replaced with an empty string.
+func ExpandPath(path string) (string, error) {
+	if strings.HasPrefix(path, "~") {
+		name, rest := path[1:], ""
+		if i := strings.IndexAny(name, `/\`); i > -1 {
+			name, rest = name[:i], name[i:]
+		}
+
+		var home string
+		if name == "" {
+			h, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("zli.ExpandPath: %w", err)
+			}
+			home = h
+		} else {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", fmt.Errorf("zli.ExpandPath: %w", err)
+			}
+			home = u.HomeDir
+		}
+		path = home + rest
+	}
+	return os.ExpandEnv(path), nil
+}
+
+// ExpandPaths makes InputOrFile() and OutputOrFile() expand the path with
+// ExpandPath().
+var ExpandPaths = false
+
+// DashIsStdio makes InputOrFile() and OutputOrFile() use stdin or stdout if
+// the path is "-". If this is false only "" uses stdin or stdout, and "-" is a
+// regular file.
+//
+// A file named "-" can always be used with "./-".
+var DashIsStdio = true
+
+// IsStdio reports if InputOrFile() and OutputOrFile() use stdin or stdout for
+// this path, rather than opening a file. This can be used to adjust messages:
+//
+//	if zli.IsStdio(path) {
+//	    path = "stdin"
+//	}
+func IsStdio(path string) bool { return path == "" || (DashIsStdio && path == "-") }
+
 // StdinMessage is the message InputOrFile() and InputOnArgs() use to notify
 // the user the program is reading from stdin.
 var StdinMessage = "reading from stdin..."
 
 // InputOrFile returns a reader connected to stdin if path is "" or "-", or open
-// a path for any other value. The Close method for stdin is a no-op.
+// a path for any other value. The Close method for stdin is a no-op. See
+// DashIsStdio and IsStdio() for the handling of "-".
 //
 // It prints StdinMessage to stderr notifying the user it's reading from stdin
 // if the terminal is interactive and quiet is false.
 // See: https://www.arp242.net/read-stdin.html
 func InputOrFile(path string, quiet bool) (io.ReadCloser, error) {
-	if path != "" && path != "-" {
+	if !IsStdio(path) {
+		if ExpandPaths {
+			var err error
+			path, err = ExpandPath(path)
+			if err != nil {
+				return nil, fmt.Errorf("zli.InputOrFile: %w", err)
+			}
+		}
 		fp, err := os.Open(path)
 		if err != nil {
 			err = fmt.Errorf("zli.InputOrFile: %w", err)
@@ -103,7 +397,8 @@ type nopCloser struct{ io.Writer }
 func (nopCloser) Close() error { return nil }
 
 // OutputOrFile returns a writer connected to stdout if path is "" or "-", or
-// open a path for any other value. The Close method for stdout is a no-op.
+// open a path for any other value. The Close method for stdout is a no-op. See
+// DashIsStdio and IsStdio() for the handling of "-".
 //
 // The create function is used to open the file; the simplest is to use
 // [os.Create]:
@@ -116,7 +411,14 @@ func (nopCloser) Close() error { return nil }
 //	    return os.OpenFile(p, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
 //	})
 func OutputOrFile(path string, create func(string) (*os.File, error)) (io.WriteCloser, error) {
-	if path != "" && path != "-" {
+	if !IsStdio(path) {
+		if ExpandPaths {
+			var err error
+			path, err = ExpandPath(path)
+			if err != nil {
+				return nil, fmt.Errorf("zli.OutputOrFile: %w", err)
+			}
+		}
 		fp, err := create(path)
 		//fp, err := os.Create(path)
 		if err != nil {
@@ -166,6 +468,39 @@ func InputOrArgs(args []string, sep string, quiet bool) ([]string, error) {
 	}), nil
 }
 
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// Capture runs fn and returns everything written to Stdout and Stderr.
+//
+// The previous Stdout and Stderr are restored afterwards (also if fn panics),
+// so Capture() can be nested. Because Stdout and Stderr are global this also
+// captures the output of other goroutines running at the same time; writes to
+// the buffers are synchronized, so it's safe if fn starts goroutines that print
+// output.
+func Capture(fn func()) (stdout, stderr string) {
+	var o, e lockedBuffer
+	saveOut, saveErr := Stdout, Stderr
+	Stdout, Stderr = &o, &e
+	defer func() { Stdout, Stderr = saveOut, saveErr }()
+
+	fn()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return o.buf.String(), e.buf.String()
+}
+
 // PagerStdout replaces Stdout with a buffer and pipes the content of it to
 // $PAGER.
 //
@@ -175,7 +510,8 @@ func InputOrArgs(args []string, sep string, quiet bool) ([]string, error) {
 //
 // You need to be a bit careful when calling Exit() explicitly, since that will
 // exit immediately without running any defered functions. You have to either
-// use a wrapper or call the returned function explicitly.
+// use a wrapper, call the returned function explicitly, or register it with
+// AtExit().
 func PagerStdout() func() {
 	buf := new(bytes.Buffer)
 	save := Stdout
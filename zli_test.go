@@ -11,6 +11,7 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -46,8 +47,8 @@ func TestFatal(t *testing.T) {
 				Fatalf(tt.in, tt.args...)
 			}()
 
-			if *exit != 1 {
-				t.Errorf("wrong exit: %d", *exit)
+			if exit.Code() != 1 {
+				t.Errorf("wrong exit: %d", exit.Code())
 			}
 			got := out.String()
 			if got != tt.want {
@@ -77,8 +78,8 @@ func TestF(t *testing.T) {
 			F(errors.New("oh noes"))
 		}()
 
-		if *exit != 1 {
-			t.Errorf("wrong exit: %d", *exit)
+		if exit.Code() != 1 {
+			t.Errorf("wrong exit: %d", exit.Code())
 		}
 		if out.String() != "zli.test: oh noes\n" {
 			t.Errorf("wrong out: %q", out.String())
@@ -86,6 +87,259 @@ func TestF(t *testing.T) {
 	})
 }
 
+func TestPrint(t *testing.T) {
+	_, _, out := Test(t)
+	errOut := new(bytes.Buffer)
+	Stderr = errOut
+
+	Print("a", 1)
+	Printf("b %d", 2)
+	Println("c", 3)
+	Eprint("d", 4)
+	Eprintf("e %d", 5)
+	Eprintln("f", 6)
+
+	if out.String() != "a1b 2c 3\n" {
+		t.Errorf("wrong stdout: %q", out.String())
+	}
+	if errOut.String() != "d4e 5f 6\n" {
+		t.Errorf("wrong stderr: %q", errOut.String())
+	}
+}
+
+type fdBuffer struct{ *bytes.Buffer }
+
+func (fdBuffer) Fd() uintptr { return 0 }
+
+func TestRule(t *testing.T) {
+	tests := []struct {
+		title  string
+		center bool
+		width  int
+		want   string
+	}{
+		{"", false, 0, strings.Repeat("─", 80)},
+		{"", false, 10, "──────────"},
+		{"Title", false, 15, "── \x1b[1mTitle\x1b[0m ──────"},
+		{"Title", true, 15, "──── \x1b[1mTitle\x1b[0m ────"},
+		{"Title", true, 16, "──── \x1b[1mTitle\x1b[0m ─────"},
+		{"A long title", false, 10, "── \x1b[1mA long title\x1b[0m "},
+		{"A long title", true, 10, " \x1b[1mA long title\x1b[0m "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			_, _, out := Test(t, TestColor(true))
+			if tt.width > 0 {
+				save := TerminalSize
+				TerminalSize = func(uintptr) (int, int, error) { return tt.width, 25, nil }
+				defer func() { TerminalSize = save }()
+				Stdout = fdBuffer{out}
+			}
+
+			if tt.center {
+				RuleCenter(tt.title)
+			} else {
+				Rule(tt.title)
+			}
+			if have := out.String(); have != tt.want+"\n" {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want+"\n")
+			}
+		})
+	}
+}
+
+func TestCapture(t *testing.T) {
+	_, _, out := Test(t)
+
+	Println("before")
+	o, e := Capture(func() {
+		Println("out")
+		Errorf("err")
+
+		o, e := Capture(func() {
+			Println("nested")
+		})
+		if o != "nested\n" || e != "" {
+			t.Errorf("wrong nested output: %q %q", o, e)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				Print("x")
+			}()
+		}
+		wg.Wait()
+	})
+	Println("after")
+
+	if o != "out\nxxxxxxxxxx" {
+		t.Errorf("wrong stdout: %q", o)
+	}
+	if e != "zli.test: err\n" {
+		t.Errorf("wrong stderr: %q", e)
+	}
+	if out.String() != "before\nafter\n" {
+		t.Errorf("wrong output: %q", out.String())
+	}
+}
+
+func TestSetProgram(t *testing.T) {
+	_, _, out := Test(t)
+	SetProgram("prog")
+	defer SetProgram("")
+
+	Errorf("oh noes")
+	if have := Usage(UsageProgram, "%(prog)"); have != "prog" {
+		t.Errorf("wrong usage: %q", have)
+	}
+	PrintVersion(false)
+
+	if !strings.HasPrefix(out.String(), "prog: oh noes\nprog ") {
+		t.Errorf("wrong out: %q", out.String())
+	}
+
+	SetProgram("")
+	if Program() != "zli.test" {
+		t.Errorf("wrong program: %q", Program())
+	}
+}
+
+func TestFatalCode(t *testing.T) {
+	exit, _, out := Test(t)
+
+	func() {
+		defer exit.Recover()
+		FatalCode(ExitUsage, "oh noes: %d", 42)
+	}()
+
+	exit.Want(t, 64)
+	if out.String() != "zli.test: oh noes: 42\n" {
+		t.Errorf("wrong out: %q", out.String())
+	}
+
+	out.Reset()
+	func() {
+		defer exit.Recover()
+		Fatalx(ExitConfig, "oh noes")
+	}()
+	exit.Want(t, 78)
+	if out.String() != "zli.test: oh noes\n" {
+		t.Errorf("wrong out: %q", out.String())
+	}
+}
+
+func TestRecover(t *testing.T) {
+	exit, _, out := Test(t)
+	defer func(c bool) { WantColorStderr = c }(WantColorStderr)
+	WantColorStderr = false
+
+	func() {
+		defer exit.Recover()
+		func() {
+			defer Recover()
+			panic("oh noes %s")
+		}()
+	}()
+
+	exit.Want(t, ExitSoftware)
+	if out.String() != "zli.test: panic: oh noes %s\n" {
+		t.Errorf("wrong out: %q", out.String())
+	}
+}
+
+func TestAtExit(t *testing.T) {
+	var order []int
+	AtExit(func() { order = append(order, 1) })
+	AtExit(func() { order = append(order, 2) })
+	remove := addAtExit(func() { order = append(order, 3) })
+	remove()
+	remove()
+
+	RunAtExit()
+	RunAtExit()
+	if !reflect.DeepEqual(order, []int{2, 1}) {
+		t.Errorf("wrong order: %v", order)
+	}
+}
+
+func TestErrorPrefix(t *testing.T) {
+	defer func(p string, f Color, c bool) { ErrorPrefix, FormatErrorPrefix, WantColorStderr = p, f, c }(
+		ErrorPrefix, FormatErrorPrefix, WantColorStderr)
+
+	tests := []struct {
+		prefix string
+		format Color
+		want   string
+	}{
+		{"%(prog): ", Reset, "zli.test: oh noes\n"},
+		{"", Reset, "oh noes\n"},
+		{"%(prog): error: ", Reset, "zli.test: error: oh noes\n"},
+		{"%(prog): ", Red, "\x1b[31mzli.test: \x1b[0moh noes\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			_, _, out := Test(t)
+			ErrorPrefix, FormatErrorPrefix, WantColorStderr = tt.prefix, tt.format, true
+
+			Errorf("oh noes")
+			if out.String() != tt.want {
+				t.Errorf("\ngot:  %q\nwant: %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	t.Setenv("HOME", "/home/zli")
+	t.Setenv("ZLI_TEST", "val")
+	if runtime.GOOS == "windows" {
+		t.Setenv("USERPROFILE", "/home/zli")
+	}
+
+	tests := []struct {
+		in, want, wantErr string
+	}{
+		{"", "", ""},
+		{"/foo/bar", "/foo/bar", ""},
+		{"~", "/home/zli", ""},
+		{"~/", "/home/zli/", ""},
+		{"~/foo/$ZLI_TEST/${ZLI_TEST}x", "/home/zli/foo/val/valx", ""},
+		{"foo/~/bar", "foo/~/bar", ""},
+		{"$ZLI_TEST_UNSET/x", "/x", ""},
+		{"~zli-nonexistent-user/x", "", "zli-nonexistent-user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			have, err := ExpandPath(tt.in)
+			if !errorContains(err, tt.wantErr) {
+				t.Fatalf("wrong error\nhave: %v\nwant: %v", err, tt.wantErr)
+			}
+			if have != tt.want {
+				t.Errorf("\nhave: %q\nwant: %q", have, tt.want)
+			}
+		})
+	}
+
+	t.Run("InputOrFile", func(t *testing.T) {
+		wd, _ := os.Getwd()
+		t.Setenv("ZLI_TEST", wd)
+		ExpandPaths = true
+		defer func() { ExpandPaths = false }()
+
+		fp, err := InputOrFile("$ZLI_TEST/zli_test.go", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fp.Close()
+	})
+}
+
 func TestInputOrFile(t *testing.T) {
 	tests := []struct {
 		in            string
@@ -294,3 +548,32 @@ func errorContains(out error, want string) bool {
 	}
 	return strings.Contains(out.Error(), want)
 }
+
+func TestDashIsStdio(t *testing.T) {
+	defer func(d bool) { DashIsStdio = d }(DashIsStdio)
+	_, in, _ := Test(t)
+	in.WriteString("stdin")
+
+	if !IsStdio("") || !IsStdio("-") || IsStdio("./-") || IsStdio("file") {
+		t.Error("IsStdio wrong")
+	}
+
+	DashIsStdio = false
+	if !IsStdio("") || IsStdio("-") {
+		t.Error("IsStdio wrong with DashIsStdio=false")
+	}
+
+	_, err := InputOrFile("-", true)
+	if !errorContains(err, "open -: no such file or directory") {
+		t.Errorf("wrong error: %v", err)
+	}
+
+	fp, err := InputOrFile("", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadAll(fp)
+	if string(got) != "stdin" {
+		t.Errorf("wrong stdin: %q", got)
+	}
+}